@@ -1 +1,52 @@
 package gox
+
+import (
+	"fmt"
+	"gox/internal"
+	"gox/internal/linting"
+	"gox/internal/parsing"
+	"gox/internal/scanning"
+	"gox/internal/typing"
+	"io"
+)
+
+// ReportError writes diagnostic message about error on given line to w
+func ReportError(w io.Writer, line int, message, where string) {
+	_, _ = fmt.Fprintf(w, "[line %d] error %s: %s\n", line, where, message)
+}
+
+// ReportRuntimeError writes runtime error to w, errors of the interpreter itself
+// are not related to any line of the program
+func ReportRuntimeError(w io.Writer, runtimeError *internal.RuntimeError) {
+	if runtimeError.Token == nil {
+		_, _ = fmt.Fprintf(w, "error: %v\n", runtimeError.Error)
+		return
+	}
+	ReportError(w, runtimeError.Token.Line, runtimeError.Error.Error(), "")
+}
+
+func ReportSyntaxError(w io.Writer, syntaxError *scanning.SyntaxError) {
+	if syntaxError.Column > 0 {
+		ReportError(w, syntaxError.Line, syntaxError.Error(), fmt.Sprintf("at column %d", syntaxError.Column))
+	} else {
+		ReportError(w, syntaxError.Line, syntaxError.Error(), "")
+	}
+}
+
+func ReportParseError(w io.Writer, parseError *parsing.ParseError) {
+	token := parseError.Token
+	if token.TokenType == scanning.EOF {
+		ReportError(w, token.Line, parseError.Error(), "")
+	} else {
+		ReportError(w, token.Line, parseError.Error(), token.Lexeme)
+	}
+}
+
+func ReportTypeError(w io.Writer, typeError *typing.TypeError) {
+	ReportError(w, typeError.Token.Line, typeError.Error(), typeError.Token.Lexeme)
+}
+
+func ReportLintDiagnostic(w io.Writer, diagnostic *linting.Diagnostic) {
+	message := fmt.Sprintf("%s [%s]", diagnostic.Message, diagnostic.Rule)
+	ReportError(w, diagnostic.Token.Line, message, diagnostic.Token.Lexeme)
+}
@@ -0,0 +1,89 @@
+package gox
+
+import (
+	"fmt"
+	"gox/internal/linting"
+	"gox/internal/scanning"
+	"gox/internal/typing"
+)
+
+// SyntaxError is returned when source code could not be tokenized
+type SyntaxError struct {
+	Line   int
+	Column int // 0 if unknown
+	Err    error
+}
+
+func (e *SyntaxError) Error() string {
+	if e.Column > 0 {
+		return fmt.Sprintf("syntax error at line %d, column %d: %v", e.Line, e.Column, e.Err)
+	}
+	return fmt.Sprintf("syntax error at line %d: %v", e.Line, e.Err)
+}
+
+func (e *SyntaxError) Unwrap() error {
+	return e.Err
+}
+
+// ParseError is returned when tokens do not form valid program
+type ParseError struct {
+	Token *scanning.Token
+	Err   error
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("parse error at line %d: %v", e.Token.Line, e.Err)
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// RuntimeError is returned when program fails during execution
+type RuntimeError struct {
+	Line int
+	Err  error
+}
+
+func (e *RuntimeError) Error() string {
+	return fmt.Sprintf("runtime error at line %d: %v", e.Line, e.Err)
+}
+
+func (e *RuntimeError) Unwrap() error {
+	return e.Err
+}
+
+// IOError is returned when script or input could not be read
+type IOError struct {
+	Path string
+	Err  error
+}
+
+func (e *IOError) Error() string {
+	if e.Path == "" {
+		return fmt.Sprintf("unable to read input: %v", e.Err)
+	}
+	return fmt.Sprintf("unable to read %s: %v", e.Path, e.Err)
+}
+
+func (e *IOError) Unwrap() error {
+	return e.Err
+}
+
+// TypeError is returned when type checker finds problems in the program
+type TypeError struct {
+	Errors []*typing.TypeError
+}
+
+func (e *TypeError) Error() string {
+	return fmt.Sprintf("found %d type errors", len(e.Errors))
+}
+
+// LintError is returned when linter finds problems in the program
+type LintError struct {
+	Diagnostics []*linting.Diagnostic
+}
+
+func (e *LintError) Error() string {
+	return fmt.Sprintf("found %d lint problems", len(e.Diagnostics))
+}
@@ -2,77 +2,181 @@ package gox
 
 import (
 	"bufio"
+	"errors"
 	"fmt"
+	"gox/internal/ast"
+	"gox/internal/linting"
+	"gox/internal/optimizing"
 	"gox/internal/parsing"
 	"gox/internal/runtime"
 	"gox/internal/scanning"
+	"gox/internal/typing"
+	"io"
 	"os"
 	"path/filepath"
 )
 
+// stdinPath names standard input in place of script path
+const stdinPath = "-"
+
 type Gox struct {
 	Interpreter *runtime.Interpreter
+	// DisableOptimizations runs programs exactly as parsed, without constant folding
+	// and dead code elimination
+	DisableOptimizations bool
+	input                *bufio.Reader
 }
 
+// RunFile executes script stored at path. Errors are reported to interpreter's
+// stderr and returned as one of SyntaxError, ParseError, RuntimeError or IOError.
 func (r *Gox) RunFile(path string) error {
-	absPath, err := filepath.Abs(path)
+	source, err := r.readScript(path)
 	if err != nil {
-		return fmt.Errorf("%w", err)
+		return err
 	}
-	file, err := os.ReadFile(absPath)
+	return r.run(source)
+}
+
+// RunString executes source code given directly, e.g. on command line
+func (r *Gox) RunString(source string) error {
+	return r.run(source)
+}
+
+// RunStdin executes script read from interpreter's stdin
+func (r *Gox) RunStdin() error {
+	return r.RunFile(stdinPath)
+}
+
+// CheckFile statically checks types of script stored at path without running
+// it. All found problems are reported and returned as TypeError.
+func (r *Gox) CheckFile(path string) error {
+	source, err := r.readScript(path)
 	if err != nil {
-		fmt.Println("An error occurred while reading file. Please try again", err)
 		return err
 	}
-	err = r.run(string(file))
+	statements, _, err := r.compile(source)
 	if err != nil {
-		_ = fmt.Errorf("unable to run script: %w", err)
-		os.Exit(65)
+		return err
 	}
-	return nil
+
+	typeErrors := typing.NewChecker(nativeNames()).Check(statements)
+	if len(typeErrors) == 0 {
+		return nil
+	}
+	for _, typeError := range typeErrors {
+		ReportTypeError(r.Interpreter.Stderr, typeError)
+	}
+	return &TypeError{Errors: typeErrors}
 }
 
-func (r *Gox) Repl() {
-	fmt.Print("> ")
-	reader := bufio.NewReader(os.Stdin)
-	input, err := reader.ReadString('\n')
+// LintFile reports suspicious constructs in script stored at path without
+// running it. All found problems are reported and returned as LintError.
+func (r *Gox) LintFile(path string, config linting.Config) error {
+	source, err := r.readScript(path)
+	if err != nil {
+		return err
+	}
+	statements, comments, err := r.compile(source)
 	if err != nil {
-		if err.Error() == "EOF" {
-			fmt.Println("Bye!")
+		return err
+	}
+
+	diagnostics := linting.NewLinter(config, nativeNames()).Lint(statements, comments)
+	if len(diagnostics) == 0 {
+		return nil
+	}
+	for _, diagnostic := range diagnostics {
+		ReportLintDiagnostic(r.Interpreter.Stderr, diagnostic)
+	}
+	return &LintError{Diagnostics: diagnostics}
+}
+
+// Repl reads and executes input line by line until the input is exhausted.
+// Errors in executed lines are reported but do not stop the loop.
+func (r *Gox) Repl() error {
+	if r.input == nil {
+		r.input = bufio.NewReader(r.Interpreter.Stdin)
+	}
+	for {
+		_, _ = fmt.Fprint(r.Interpreter.Stdout, "> ")
+		input, err := r.input.ReadString('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			ioErr := &IOError{Err: err}
+			_, _ = fmt.Fprintln(r.Interpreter.Stderr, ioErr)
+			return ioErr
+		}
+		_ = r.run(input)
+		if errors.Is(err, io.EOF) {
+			_, _ = fmt.Fprintln(r.Interpreter.Stdout, "Bye!")
+			return nil
 		}
-		fmt.Println("An error occurred while reading input. Please try again", err)
 	}
-	err = r.run(input)
 }
 
 // run makes necessary calls to execute the source code
 func (r *Gox) run(source string) error {
-	tokens, syntaxErr := scanning.NewLexer(source).ScanTokens()
-	if syntaxErr != nil {
-		ReportError(syntaxErr.Line, syntaxErr.Error(), "")
-		return syntaxErr
+	statements, _, err := r.compile(source)
+	if err != nil {
+		return err
 	}
-	ast, parseErr := parsing.NewParser(tokens).Parse()
-	if parseErr != nil {
-		ReportParseError(parseErr)
-		return parseErr
+	if !r.DisableOptimizations {
+		statements = optimizing.NewOptimizer().Optimize(statements)
 	}
-	interpreterErr := r.Interpreter.Interpret(ast)
+	interpreterErr := r.Interpreter.Interpret(statements)
 	if interpreterErr != nil {
-		ReportError(interpreterErr.Token.Line, interpreterErr.Error.Error(), "")
+		ReportRuntimeError(r.Interpreter.Stderr, interpreterErr)
+		runtimeErr := &RuntimeError{Err: interpreterErr.Error}
+		if interpreterErr.Token != nil {
+			runtimeErr.Line = interpreterErr.Token.Line
+		}
+		return runtimeErr
 	}
 	return nil
 }
 
-func ReportError(line int, message, where string) {
-	fmt.Printf("[line %d] error %s: %s\n", line, where, message)
+// compile turns source code into statements, reporting syntax and parse errors.
+// Comments of the source are returned as well for tools that interpret them.
+func (r *Gox) compile(source string) ([]*ast.Stmt, []scanning.Comment, error) {
+	lexer := scanning.NewLexer(source)
+	tokens, syntaxErr := lexer.ScanTokens()
+	if syntaxErr != nil {
+		ReportSyntaxError(r.Interpreter.Stderr, syntaxErr)
+		return nil, nil, &SyntaxError{Line: syntaxErr.Line, Column: syntaxErr.Column, Err: syntaxErr}
+	}
+	statements, parseErr := parsing.NewParser(tokens).Parse()
+	if parseErr != nil {
+		ReportParseError(r.Interpreter.Stderr, parseErr)
+		return nil, nil, &ParseError{Token: parseErr.Token, Err: parseErr}
+	}
+	return statements, lexer.Comments(), nil
 }
 
-func ReportParseError(parseError *parsing.ParseError) {
-	token := parseError.Token
-	if token.TokenType == scanning.EOF {
-		ReportError(token.Line, parseError.Error(), "")
+// readScript returns content of script at path, or of interpreter's stdin if path is "-"
+func (r *Gox) readScript(path string) (string, error) {
+	var source []byte
+	var err error
+	if path == stdinPath {
+		source, err = io.ReadAll(r.Interpreter.Stdin)
 	} else {
-		ReportError(token.Line, parseError.Error(), token.Lexeme)
+		var absPath string
+		absPath, err = filepath.Abs(path)
+		if err == nil {
+			source, err = os.ReadFile(absPath)
+		}
+	}
+	if err != nil {
+		ioErr := &IOError{Path: path, Err: err}
+		_, _ = fmt.Fprintln(r.Interpreter.Stderr, ioErr)
+		return "", ioErr
+	}
+	return string(source), nil
+}
+
+// nativeNames returns names of globals defined by the interpreter itself
+func nativeNames() []string {
+	names := make([]string, len(runtime.StdFunctions))
+	for i, fn := range runtime.StdFunctions {
+		names[i] = fn.Name()
 	}
+	return names
 }
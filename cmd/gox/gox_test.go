@@ -0,0 +1,205 @@
+package gox
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"gox/internal/linting"
+	"gox/internal/runtime"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+const testdataDir = "testdata"
+
+var (
+	expectOutputPattern       = regexp.MustCompile(`// expect: ?(.*)$`)
+	expectRuntimeErrorPattern = regexp.MustCompile(`// expect runtime error: (.+)$`)
+	expectCompileErrorPattern = regexp.MustCompile(`// expect compile error: (.+)$`)
+	expectTypeErrorPattern    = regexp.MustCompile(`// expect type error: (.+)$`)
+	expectLintPattern         = regexp.MustCompile(`// expect lint: (.+)$`)
+	reportedErrorPattern      = regexp.MustCompile(`^\[line (\d+)\] error [^:]*: (.*)$`)
+)
+
+// expectation holds everything a golden script declares about its own execution
+type expectation struct {
+	output []string
+	// error is the message of expected runtime or compile error
+	error      string
+	errorLine  int
+	typeErrors []string
+	lints      []string
+}
+
+// TestGolden runs every script under testdata and compares what it prints with
+// the "// expect: ", "// expect runtime error: " and "// expect compile error: "
+// annotations in its source.
+// Scripts annotated with "// expect type error: " are type checked and scripts
+// annotated with "// expect lint: " are linted instead.
+func TestGolden(t *testing.T) {
+	scripts := findScripts(t)
+	if len(scripts) == 0 {
+		t.Fatalf("no scripts found in %s", testdataDir)
+	}
+	for _, script := range scripts {
+		script := script
+		name := strings.TrimSuffix(filepath.ToSlash(script), ".lox")
+		t.Run(strings.TrimPrefix(name, testdataDir+"/"), func(t *testing.T) {
+			source, err := os.ReadFile(script)
+			if err != nil {
+				t.Fatal(err)
+			}
+			expected := parseExpectation(string(source))
+			if len(expected.typeErrors) > 0 {
+				verifyDiagnostics(t, expected.typeErrors, checkScript(script))
+				return
+			}
+			if len(expected.lints) > 0 {
+				verifyDiagnostics(t, expected.lints, lintScript(script))
+				return
+			}
+			output, diagnostics := runScript(string(source), false)
+			expected.verify(t, output, diagnostics)
+
+			// optimizations must not change behaviour of the program
+			unoptimizedOutput, unoptimizedDiagnostics := runScript(string(source), true)
+			if strings.Join(unoptimizedOutput, "\n") != strings.Join(output, "\n") ||
+				strings.Join(unoptimizedDiagnostics, "\n") != strings.Join(diagnostics, "\n") {
+				t.Errorf("optimized program behaves differently\noptimized:   %q %q\nunoptimized: %q %q",
+					output, diagnostics, unoptimizedOutput, unoptimizedDiagnostics)
+			}
+		})
+	}
+}
+
+func findScripts(t *testing.T) []string {
+	var scripts []string
+	err := filepath.WalkDir(testdataDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() && filepath.Ext(path) == ".lox" {
+			scripts = append(scripts, path)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return scripts
+}
+
+func parseExpectation(source string) expectation {
+	res := expectation{output: make([]string, 0)}
+	scanner := bufio.NewScanner(strings.NewReader(source))
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := scanner.Text()
+		if match := expectLintPattern.FindStringSubmatch(text); match != nil {
+			res.lints = append(res.lints, fmt.Sprintf("[line %d] %s", line, match[1]))
+		} else if match := expectTypeErrorPattern.FindStringSubmatch(text); match != nil {
+			res.typeErrors = append(res.typeErrors, fmt.Sprintf("[line %d] %s", line, match[1]))
+		} else if match := expectRuntimeErrorPattern.FindStringSubmatch(text); match != nil {
+			res.error = match[1]
+			res.errorLine = line
+		} else if match := expectCompileErrorPattern.FindStringSubmatch(text); match != nil {
+			res.error = match[1]
+			res.errorLine = line
+		} else if match := expectOutputPattern.FindStringSubmatch(text); match != nil {
+			res.output = append(res.output, match[1])
+		}
+	}
+	return res
+}
+
+// runScript executes source through Gox and returns lines written to stdout and
+// stderr respectively
+func runScript(source string, disableOptimizations bool) ([]string, []string) {
+	var stdout, stderr bytes.Buffer
+	g := Gox{
+		Interpreter:          runtime.NewInterpreterWithIO(strings.NewReader(""), &stdout, &stderr),
+		DisableOptimizations: disableOptimizations,
+	}
+	// compile errors are reported on stderr, so the returned error carries no
+	// information the diagnostics do not
+	_ = g.run(source)
+	return splitLines(stdout.String()), splitLines(stderr.String())
+}
+
+// checkScript type checks script at path and returns reported diagnostics
+func checkScript(path string) []string {
+	var stdout, stderr bytes.Buffer
+	g := Gox{Interpreter: runtime.NewInterpreterWithIO(strings.NewReader(""), &stdout, &stderr)}
+	_ = g.CheckFile(path)
+	return splitLines(stderr.String())
+}
+
+// lintScript lints script at path with all rules enabled and returns reported diagnostics
+func lintScript(path string) []string {
+	var stdout, stderr bytes.Buffer
+	g := Gox{Interpreter: runtime.NewInterpreterWithIO(strings.NewReader(""), &stdout, &stderr)}
+	_ = g.LintFile(path, linting.DefaultConfig())
+	return splitLines(stderr.String())
+}
+
+func splitLines(text string) []string {
+	text = strings.TrimSuffix(text, "\n")
+	if text == "" {
+		return make([]string, 0)
+	}
+	return strings.Split(text, "\n")
+}
+
+func (r expectation) verify(t *testing.T, output, reported []string) {
+	if len(output) != len(r.output) {
+		t.Errorf("expected %d lines of output, got %d\nexpected: %q\ngot:      %q", len(r.output), len(output), r.output, output)
+	} else {
+		for i := range output {
+			if output[i] != r.output[i] {
+				t.Errorf("output line %d: expected %q, got %q", i+1, r.output[i], output[i])
+			}
+		}
+	}
+
+	if r.error == "" {
+		for _, line := range reported {
+			t.Errorf("unexpected error: %s", line)
+		}
+		return
+	}
+	if len(reported) == 0 {
+		t.Errorf("expected error %q at line %d, got none", r.error, r.errorLine)
+		return
+	}
+	match := reportedErrorPattern.FindStringSubmatch(reported[0])
+	if match == nil {
+		t.Errorf("expected error %q at line %d, got %q", r.error, r.errorLine, reported[0])
+		return
+	}
+	line, _ := strconv.Atoi(match[1])
+	if match[2] != r.error || line != r.errorLine {
+		t.Errorf("expected error %q, got %q", fmt.Sprintf("[line %d] %s", r.errorLine, r.error), fmt.Sprintf("[line %d] %s", line, match[2]))
+	}
+}
+
+// verifyDiagnostics compares diagnostics reported by static analysis with expected ones
+func verifyDiagnostics(t *testing.T, expected, reported []string) {
+	got := make([]string, 0, len(reported))
+	for _, line := range reported {
+		match := reportedErrorPattern.FindStringSubmatch(line)
+		if match == nil {
+			t.Errorf("unexpected diagnostic: %s", line)
+			continue
+		}
+		got = append(got, fmt.Sprintf("[line %s] %s", match[1], match[2]))
+	}
+	if strings.Join(got, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("diagnostics differ\nexpected: %q\ngot:      %q", expected, got)
+	}
+}
@@ -1,34 +1,188 @@
 package main
 
 import (
+	"errors"
+	"flag"
 	"fmt"
 	"gox/cmd/gox"
+	"gox/internal/decimal"
+	"gox/internal/linting"
 	"gox/internal/runtime"
+	"io"
+	"io/fs"
 	"os"
 )
 
+// exit codes as defined by sysexits.h
+const (
+	exitUsage    = 64
+	exitDataErr  = 65
+	exitNoInput  = 66
+	exitSoftware = 70
+	exitIOErr    = 74
+	exitConfig   = 78
+	exitFailure  = 1
+)
+
+const usage = `Usage:
+  gox                          start interactive REPL
+  gox [run] <script> [args...] execute script, use - to read it from stdin
+  gox -e <source> [args...]    evaluate source given on command line
+  gox check <script>           report type errors without running script
+  gox lint [-config <file>] <script>
+                               report suspicious code, rules are configured by
+                               .goxlint.json in working directory by default
+
+Flags:
+`
+
 func main() {
-	interpreter := gox.Gox{
-		Interpreter: runtime.NewInterpreter(),
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+// run executes gox with command line args and returns the exit code
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("gox", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() {
+		_, _ = fmt.Fprint(flags.Output(), usage)
+		flags.PrintDefaults()
+	}
+	eval := flags.String("e", "", "evaluate `source` instead of reading script file")
+	noOptimize := flags.Bool("no-optimize", false, "run program without constant folding and dead code elimination")
+	decimalScale := flags.Int("decimal-scale", decimal.DefaultContext.Scale, "number of fractional `digits` kept by division of decimals")
+	decimalRounding := flags.String("decimal-rounding", "half-even",
+		"rounding `mode` of division of decimals: half-even, half-up, down, up, floor or ceiling")
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return exitUsage
 	}
 
-	args := os.Args[1:]
+	rounding, err := decimal.ParseRoundingMode(*decimalRounding)
+	if err != nil || *decimalScale < 0 {
+		_, _ = fmt.Fprintln(stderr, "invalid decimal rounding mode or scale")
+		flags.Usage()
+		return exitUsage
+	}
 
-	if len(args) > 1 {
-		fmt.Println("Too many arguments")
-		os.Exit(64)
+	interpreter := gox.Gox{
+		Interpreter:          runtime.NewInterpreterWithIO(stdin, stdout, stderr),
+		DisableOptimizations: *noOptimize,
+	}
+	interpreter.Interpreter.Decimal = decimal.Context{Scale: *decimalScale, Rounding: rounding}
+
+	args = flags.Args()
+	if isFlagSet(flags, "e") {
+		interpreter.Interpreter.Args = args
+		return exitCode(interpreter.RunString(*eval))
 	}
 
-	var err error
+	if len(args) > 0 && args[0] == "check" {
+		if len(args) != 2 {
+			_, _ = fmt.Fprintln(stderr, "check: expected exactly one script path")
+			flags.Usage()
+			return exitUsage
+		}
+		return exitCode(interpreter.CheckFile(args[1]))
+	}
+	if len(args) > 0 && args[0] == "lint" {
+		return lint(&interpreter, args[1:])
+	}
+	if len(args) > 0 && args[0] == "run" {
+		args = args[1:]
+		if len(args) == 0 {
+			_, _ = fmt.Fprintln(stderr, "run: missing script path")
+			flags.Usage()
+			return exitUsage
+		}
+	}
 	if len(args) == 0 {
-		for {
-			interpreter.Repl()
+		return exitCode(interpreter.Repl())
+	}
+
+	script := args[0]
+	interpreter.Interpreter.Args = args[1:]
+	if script == "-" {
+		return exitCode(interpreter.RunStdin())
+	}
+	return exitCode(interpreter.RunFile(script))
+}
+
+// defaultLintConfig is used by lint subcommand when present and no config is given
+const defaultLintConfig = ".goxlint.json"
+
+func lint(interpreter *gox.Gox, args []string) int {
+	flags := flag.NewFlagSet("lint", flag.ContinueOnError)
+	flags.SetOutput(interpreter.Interpreter.Stderr)
+	configPath := flags.String("config", "", "read lint rules from JSON `file`")
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return exitUsage
+	}
+	if flags.NArg() != 1 {
+		_, _ = fmt.Fprintln(interpreter.Interpreter.Stderr, "lint: expected exactly one script path")
+		flags.Usage()
+		return exitUsage
+	}
+
+	config := linting.DefaultConfig()
+	if *configPath == "" {
+		if _, err := os.Stat(defaultLintConfig); err == nil {
+			*configPath = defaultLintConfig
 		}
-	} else {
-		err = interpreter.RunFile(args[0])
 	}
-	if err != nil {
-		fmt.Printf("An error occurred: %v\n", err)
-		os.Exit(65)
+	if *configPath != "" {
+		var err error
+		config, err = linting.LoadConfig(*configPath)
+		if err != nil {
+			_, _ = fmt.Fprintln(interpreter.Interpreter.Stderr, err)
+			return exitConfig
+		}
+	}
+	return exitCode(interpreter.LintFile(flags.Arg(0), config))
+}
+
+func isFlagSet(flags *flag.FlagSet, name string) bool {
+	set := false
+	flags.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+	return set
+}
+
+// exitCode maps error returned by gox.Gox to process exit code. Errors are
+// already reported by the interpreter, so nothing is printed here.
+func exitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+	var (
+		syntaxErr  *gox.SyntaxError
+		parseErr   *gox.ParseError
+		typeErr    *gox.TypeError
+		runtimeErr *gox.RuntimeError
+		ioErr      *gox.IOError
+		lintErr    *gox.LintError
+	)
+	switch {
+	case errors.As(err, &syntaxErr), errors.As(err, &parseErr), errors.As(err, &typeErr):
+		return exitDataErr
+	case errors.As(err, &lintErr):
+		return exitFailure
+	case errors.As(err, &runtimeErr):
+		return exitSoftware
+	case errors.As(err, &ioErr):
+		if errors.Is(ioErr, fs.ErrNotExist) {
+			return exitNoInput
+		}
+		return exitIOErr
+	default:
+		return exitFailure
 	}
 }
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeScript stores source to a file named name in directory dir and returns its path
+func writeScript(t *testing.T, dir, name, source string) string {
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(source), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestRunReturnsExitCodeOfFailure(t *testing.T) {
+	dir := t.TempDir()
+	valid := writeScript(t, dir, "valid.lox", "print 1;\n")
+	parseError := writeScript(t, dir, "parse_error.lox", "print (1;\n")
+	runtimeError := writeScript(t, dir, "runtime_error.lox", "print 1 + nil;\n")
+	badConfig := writeScript(t, dir, "config.json", "{")
+
+	tests := []struct {
+		name     string
+		args     []string
+		expected int
+	}{
+		{"success", []string{valid}, 0},
+		{"missing file", []string{filepath.Join(dir, "missing.lox")}, exitNoInput},
+		{"parse error", []string{parseError}, exitDataErr},
+		{"runtime error", []string{runtimeError}, exitSoftware},
+		{"unreadable script", []string{dir}, exitIOErr},
+		{"bad flag", []string{"-unknown", valid}, exitUsage},
+		{"bad decimal scale", []string{"-decimal-scale", "-1", valid}, exitUsage},
+		{"bad config", []string{"lint", "-config", badConfig, valid}, exitConfig},
+	}
+	for _, test := range tests {
+		var stdout, stderr strings.Builder
+		code := run(test.args, strings.NewReader(""), &stdout, &stderr)
+		if code != test.expected {
+			t.Errorf("%s: expected exit code %d, got %d\nstderr: %s", test.name, test.expected, code, stderr.String())
+		}
+	}
+}
+
+func TestRunExecutesScriptInEachMode(t *testing.T) {
+	dir := t.TempDir()
+	script := writeScript(t, dir, "args.lox", "print args();\n")
+	shebang := writeScript(t, dir, "shebang.lox", "#!/usr/bin/env gox\nprint args();\n")
+
+	tests := []struct {
+		name     string
+		args     []string
+		stdin    string
+		expected string
+	}{
+		{"script", []string{script, "a", "b"}, "", "[a, b]\n"},
+		{"run subcommand", []string{"run", script, "a"}, "", "[a]\n"},
+		{"shebang", []string{shebang, "a"}, "", "[a]\n"},
+		{"flag after run", []string{"run", script, "-e"}, "", "[-e]\n"},
+		{"stdin", []string{"-", "a"}, "print args();", "[a]\n"},
+		{"evaluate", []string{"-e", "print args();", "a", "b"}, "", "[a, b]\n"},
+		{"evaluate without args", []string{"-e", "print 1 + 2;"}, "", "3\n"},
+		{"repl", nil, "print 1;\n", "> 1\n> Bye!\n"},
+	}
+	for _, test := range tests {
+		var stdout, stderr strings.Builder
+		code := run(test.args, strings.NewReader(test.stdin), &stdout, &stderr)
+		if code != 0 {
+			t.Errorf("%s: expected exit code 0, got %d\nstderr: %s", test.name, code, stderr.String())
+			continue
+		}
+		if stdout.String() != test.expected {
+			t.Errorf("%s: expected output %q, got %q", test.name, test.expected, stdout.String())
+		}
+	}
+}
+
+func TestRunWithoutScriptAfterRunIsUsageError(t *testing.T) {
+	var stdout, stderr strings.Builder
+	if code := run([]string{"run"}, strings.NewReader(""), &stdout, &stderr); code != exitUsage {
+		t.Errorf("expected exit code %d, got %d", exitUsage, code)
+	}
+	if !strings.Contains(stderr.String(), "run: missing script path") {
+		t.Errorf("expected missing script path to be reported, got %q", stderr.String())
+	}
+}
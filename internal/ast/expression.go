@@ -13,7 +13,11 @@ type ExprVisitor interface {
 	VisitForVariableExpression(expr *VarExpr) (any, *internal.RuntimeError)
 	VisitForAssignExpression(expr *Assign) (any, *internal.RuntimeError)
 	VisitForLogical(expr *Logical) (any, *internal.RuntimeError)
+	VisitForConditional(expr *Conditional) (any, *internal.RuntimeError)
 	VisitForFunctionCall(expr *Call) (any, *internal.RuntimeError)
+	VisitForLambda(expr *Lambda) (any, *internal.RuntimeError)
+	VisitForInterpolation(expr *Interpolation) (any, *internal.RuntimeError)
+	VisitForSpawn(expr *Spawn) (any, *internal.RuntimeError)
 }
 
 type Expr interface {
@@ -68,17 +72,19 @@ func (r *VarExpr) Accept(visitor ExprVisitor) (any, *internal.RuntimeError) {
 	return visitor.VisitForVariableExpression(r)
 }
 
-// Assign
+// Assign, compound assignment x += y has Operator PLUS, x++ is x += 1 with Postfix set
 type Assign struct {
-	Name  *scanning.Token
-	Value Expr
+	Name     *scanning.Token
+	Operator *scanning.Token // operator applied to current value and Value, nil for plain assignment
+	Value    Expr
+	Postfix  bool // expression results in value before assignment
 }
 
 func (r *Assign) Accept(visitor ExprVisitor) (any, *internal.RuntimeError) {
 	return visitor.VisitForAssignExpression(r)
 }
 
-// Logical
+// Logical, operator is AND, OR or QUESTION_QUESTION, which results in Left unless it is nil
 type Logical struct {
 	Left     Expr
 	Operator *scanning.Token
@@ -89,13 +95,64 @@ func (r *Logical) Accept(visitor ExprVisitor) (any, *internal.RuntimeError) {
 	return visitor.VisitForLogical(r)
 }
 
+// Conditional, condition ? Then : Else
+type Conditional struct {
+	Condition Expr
+	Question  *scanning.Token
+	Then      Expr
+	Else      Expr
+}
+
+func (r *Conditional) Accept(visitor ExprVisitor) (any, *internal.RuntimeError) {
+	return visitor.VisitForConditional(r)
+}
+
 // Call
 type Call struct {
 	Callee Expr
 	Paren  *scanning.Token
 	Params []Expr
+	Named  []*NamedArg // arguments passed by parameter name, following Params
+}
+
+// NamedArg is argument of call in form "name: value"
+type NamedArg struct {
+	Name  *scanning.Token
+	Value Expr
 }
 
 func (r *Call) Accept(visitor ExprVisitor) (any, *internal.RuntimeError) {
 	return visitor.VisitForFunctionCall(r)
 }
+
+// Lambda
+type Lambda struct {
+	Keyword    *scanning.Token // 'fun' or '=>' introducing the function
+	Params     []*Param
+	ReturnType *scanning.Token // optional type annotation
+	Body       []Stmt
+}
+
+func (r *Lambda) Accept(visitor ExprVisitor) (any, *internal.RuntimeError) {
+	return visitor.VisitForLambda(r)
+}
+
+// Interpolation is string literal with embedded expressions, e.g. "count: ${n}"
+type Interpolation struct {
+	Quote *scanning.Token // first part of the literal
+	Parts []Expr          // string literals alternating with interpolated expressions
+}
+
+func (r *Interpolation) Accept(visitor ExprVisitor) (any, *internal.RuntimeError) {
+	return visitor.VisitForInterpolation(r)
+}
+
+// Spawn starts Call on a new task and results in the task
+type Spawn struct {
+	Keyword *scanning.Token
+	Call    *Call
+}
+
+func (r *Spawn) Accept(visitor ExprVisitor) (any, *internal.RuntimeError) {
+	return visitor.VisitForSpawn(r)
+}
@@ -6,17 +6,23 @@ import (
 )
 
 type StmtVisitor interface {
-	VisitForExpression(stmt *Expression) *internal.RuntimeError
-	VisitForPrint(stmt *Print) *internal.RuntimeError
-	VisitForVar(stmt *Var) *internal.RuntimeError
-	VisitForBlock(block *Block) *internal.RuntimeError
-	VisitForIf(ifStmt *If) *internal.RuntimeError
-	VisitForWhile(while *While) *internal.RuntimeError
-	VisitForFunction(while *Function) *internal.RuntimeError
-	VisitForReturn(ret *Return) *internal.RuntimeError
+	VisitForExpression(stmt *Expression) (internal.Completion, *internal.RuntimeError)
+	VisitForPrint(stmt *Print) (internal.Completion, *internal.RuntimeError)
+	VisitForVar(stmt *Var) (internal.Completion, *internal.RuntimeError)
+	VisitForBlock(block *Block) (internal.Completion, *internal.RuntimeError)
+	VisitForIf(ifStmt *If) (internal.Completion, *internal.RuntimeError)
+	VisitForWhile(while *While) (internal.Completion, *internal.RuntimeError)
+	VisitForFunction(while *Function) (internal.Completion, *internal.RuntimeError)
+	VisitForReturn(ret *Return) (internal.Completion, *internal.RuntimeError)
+	VisitForBreak(stmt *Break) (internal.Completion, *internal.RuntimeError)
+	VisitForContinue(stmt *Continue) (internal.Completion, *internal.RuntimeError)
+	VisitForMatch(stmt *Match) (internal.Completion, *internal.RuntimeError)
+	VisitForForIn(stmt *ForIn) (internal.Completion, *internal.RuntimeError)
+	VisitForYield(stmt *Yield) (internal.Completion, *internal.RuntimeError)
+	VisitForSelect(stmt *Select) (internal.Completion, *internal.RuntimeError)
 }
 type Stmt interface {
-	Accept(visitor StmtVisitor) *internal.RuntimeError
+	Accept(visitor StmtVisitor) (internal.Completion, *internal.RuntimeError)
 }
 
 // Expression
@@ -24,7 +30,7 @@ type Expression struct {
 	Expression *Expr
 }
 
-func (r *Expression) Accept(visitor StmtVisitor) *internal.RuntimeError {
+func (r *Expression) Accept(visitor StmtVisitor) (internal.Completion, *internal.RuntimeError) {
 	return visitor.VisitForExpression(r)
 }
 
@@ -33,26 +39,28 @@ type Print struct {
 	Expression *Expr
 }
 
-func (r *Print) Accept(visitor StmtVisitor) *internal.RuntimeError {
+func (r *Print) Accept(visitor StmtVisitor) (internal.Completion, *internal.RuntimeError) {
 	return visitor.VisitForPrint(r)
 }
 
 // Var
 type Var struct {
 	Name        *scanning.Token
+	Type        *scanning.Token // optional type annotation
 	Initializer *Expr
 }
 
-func (r *Var) Accept(visitor StmtVisitor) *internal.RuntimeError {
+func (r *Var) Accept(visitor StmtVisitor) (internal.Completion, *internal.RuntimeError) {
 	return visitor.VisitForVar(r)
 }
 
 // Block
 type Block struct {
 	Statements []Stmt
+	Brace      *scanning.Token // closing brace, nil for blocks created by desugaring
 }
 
-func (r *Block) Accept(visitor StmtVisitor) *internal.RuntimeError {
+func (r *Block) Accept(visitor StmtVisitor) (internal.Completion, *internal.RuntimeError) {
 	return visitor.VisitForBlock(r)
 }
 
@@ -63,7 +71,7 @@ type If struct {
 	Else      Stmt
 }
 
-func (r *If) Accept(visitor StmtVisitor) *internal.RuntimeError {
+func (r *If) Accept(visitor StmtVisitor) (internal.Completion, *internal.RuntimeError) {
 	return visitor.VisitForIf(r)
 }
 
@@ -71,20 +79,43 @@ func (r *If) Accept(visitor StmtVisitor) *internal.RuntimeError {
 type While struct {
 	Condition Expr
 	Statement Stmt
+	Increment Expr // optional, evaluated after each iteration, also after continue
 }
 
-func (r *While) Accept(visitor StmtVisitor) *internal.RuntimeError {
+func (r *While) Accept(visitor StmtVisitor) (internal.Completion, *internal.RuntimeError) {
 	return visitor.VisitForWhile(r)
 }
 
+// ForIn executes Body for each value of Iterable bound to variable Name
+type ForIn struct {
+	Name     *scanning.Token
+	In       *scanning.Token
+	Iterable Expr
+	Body     Stmt
+}
+
+func (r *ForIn) Accept(visitor StmtVisitor) (internal.Completion, *internal.RuntimeError) {
+	return visitor.VisitForForIn(r)
+}
+
 // Function
 type Function struct {
-	Name   *scanning.Token
-	Params []*scanning.Token
-	Body   []Stmt
+	Name       *scanning.Token
+	Params     []*Param
+	ReturnType *scanning.Token // optional type annotation
+	Body       []Stmt
+	Generator  bool // declared with fun*, calling it returns generator running the body
 }
 
-func (r *Function) Accept(visitor StmtVisitor) *internal.RuntimeError {
+// Param is a single parameter of function declaration
+type Param struct {
+	Name    *scanning.Token
+	Type    *scanning.Token // optional type annotation, of each collected argument for rest parameter
+	Default Expr            // value used when argument is missing, nil if the parameter is required
+	Rest    bool            // the parameter collects remaining arguments into a list
+}
+
+func (r *Function) Accept(visitor StmtVisitor) (internal.Completion, *internal.RuntimeError) {
 	return visitor.VisitForFunction(r)
 }
 
@@ -94,6 +125,82 @@ type Return struct {
 	Value Expr
 }
 
-func (r *Return) Accept(visitor StmtVisitor) *internal.RuntimeError {
+func (r *Return) Accept(visitor StmtVisitor) (internal.Completion, *internal.RuntimeError) {
 	return visitor.VisitForReturn(r)
 }
+
+// Yield suspends generator, passing Value to its consumer
+type Yield struct {
+	Keyword *scanning.Token
+	Value   Expr // optional, nil is yielded without it
+}
+
+func (r *Yield) Accept(visitor StmtVisitor) (internal.Completion, *internal.RuntimeError) {
+	return visitor.VisitForYield(r)
+}
+
+// Break
+type Break struct {
+	Keyword *scanning.Token
+}
+
+func (r *Break) Accept(visitor StmtVisitor) (internal.Completion, *internal.RuntimeError) {
+	return visitor.VisitForBreak(r)
+}
+
+// Continue
+type Continue struct {
+	Keyword *scanning.Token
+}
+
+func (r *Continue) Accept(visitor StmtVisitor) (internal.Completion, *internal.RuntimeError) {
+	return visitor.VisitForContinue(r)
+}
+
+// Match executes body of the first case with a pattern matching Value and a truthy guard
+type Match struct {
+	Keyword *scanning.Token
+	Value   Expr
+	Cases   []*Case
+}
+
+// Case is a single case of match statement
+type Case struct {
+	Keyword  *scanning.Token
+	Patterns []*Pattern // alternatives, at least one
+	Guard    Expr       // optional condition evaluated after a pattern matches
+	Body     Stmt
+}
+
+// Pattern matches value equal to Value, or within range Value..To when To is set,
+// pattern without Value matches anything and binds it to variable Binding unless it is _
+type Pattern struct {
+	Value     Expr
+	To        Expr
+	Inclusive bool // range includes To
+	Binding   *scanning.Token
+}
+
+func (r *Match) Accept(visitor StmtVisitor) (internal.Completion, *internal.RuntimeError) {
+	return visitor.VisitForMatch(r)
+}
+
+// Select waits until one of its cases can send or receive and executes its body
+type Select struct {
+	Keyword *scanning.Token
+	Cases   []*SelectCase
+}
+
+// SelectCase is recv(Channel), Binding = recv(Channel) or send(Channel, Value),
+// case without Channel is the default one executed when no other case is ready
+type SelectCase struct {
+	Keyword *scanning.Token
+	Binding *scanning.Token // optional variable receiving the value
+	Channel Expr
+	Value   Expr // value to send, nil for receiving case
+	Body    Stmt
+}
+
+func (r *Select) Accept(visitor StmtVisitor) (internal.Completion, *internal.RuntimeError) {
+	return visitor.VisitForSelect(r)
+}
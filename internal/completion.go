@@ -0,0 +1,25 @@
+package internal
+
+// ControlFlow tells where execution continues after a statement
+type ControlFlow int
+
+const (
+	// Normal continues with the next statement
+	Normal ControlFlow = iota
+	// Return leaves the enclosing function
+	Return
+	// Break leaves the enclosing loop
+	Break
+	// Continue starts next iteration of the enclosing loop
+	Continue
+)
+
+// Completion is the result of executing a statement. Statements failing at
+// runtime, i.e. throwing, return *RuntimeError alongside instead.
+type Completion struct {
+	Flow  ControlFlow
+	Value any // value of Return completion
+}
+
+// NormalCompletion is the result of statement after which execution simply continues
+var NormalCompletion = Completion{Flow: Normal}
@@ -0,0 +1,240 @@
+// Package decimal implements exact decimal numbers of arbitrary size
+package decimal
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+var (
+	DivisionByZero  = errors.New("division by zero")
+	MissingRounding = errors.New("rounding mode of decimal division is not set")
+	InvalidDecimal  = errors.New("invalid decimal")
+)
+
+// RoundingMode selects how result of division is rounded to the scale of Context
+type RoundingMode int
+
+const (
+	HalfEven RoundingMode = iota + 1 // to nearest, ties to even digit
+	HalfUp                           // to nearest, ties away from zero
+	Down                             // towards zero
+	Up                               // away from zero
+	Floor                            // towards negative infinity
+	Ceiling                          // towards positive infinity
+)
+
+var roundingModes = map[string]RoundingMode{
+	"half-even": HalfEven,
+	"half-up":   HalfUp,
+	"down":      Down,
+	"up":        Up,
+	"floor":     Floor,
+	"ceiling":   Ceiling,
+}
+
+// ParseRoundingMode returns rounding mode of given name, e.g. "half-even"
+func ParseRoundingMode(name string) (RoundingMode, error) {
+	mode, ok := roundingModes[name]
+	if !ok {
+		return 0, fmt.Errorf("unknown rounding mode %s", name)
+	}
+	return mode, nil
+}
+
+// Context configures division, the only operation which can't be exact
+type Context struct {
+	Scale    int // number of digits after decimal point kept by division
+	Rounding RoundingMode
+}
+
+var DefaultContext = Context{Scale: 16, Rounding: HalfEven}
+
+// Decimal is number unscaled * 10^-scale
+type Decimal struct {
+	unscaled *big.Int
+	scale    int
+}
+
+// FromInt returns decimal equal to integer
+func FromInt(i *big.Int) Decimal {
+	return Decimal{unscaled: new(big.Int).Set(i), scale: 0}
+}
+
+// Parse reads decimal in form "123" or "123.45", the number of fractional digits
+// is kept, so "1.50" is printed back as "1.50"
+func Parse(s string) (Decimal, error) {
+	digits, scale := s, 0
+	if point := strings.IndexByte(s, '.'); point >= 0 {
+		digits = s[:point] + s[point+1:]
+		scale = len(s) - point - 1
+	}
+	unscaled, ok := new(big.Int).SetString(digits, 10)
+	if !ok {
+		return Decimal{}, fmt.Errorf("%w: %s", InvalidDecimal, s)
+	}
+	return Decimal{unscaled: unscaled, scale: scale}, nil
+}
+
+func (r Decimal) String() string {
+	digits := new(big.Int).Abs(r.unscaled).String()
+	if r.scale > 0 {
+		if len(digits) <= r.scale {
+			digits = strings.Repeat("0", r.scale-len(digits)+1) + digits
+		}
+		digits = digits[:len(digits)-r.scale] + "." + digits[len(digits)-r.scale:]
+	}
+	if r.unscaled.Sign() < 0 {
+		return "-" + digits
+	}
+	return digits
+}
+
+func (r Decimal) Add(other Decimal) Decimal {
+	a, b, scale := align(r, other)
+	return Decimal{unscaled: a.Add(a, b), scale: scale}
+}
+
+func (r Decimal) Sub(other Decimal) Decimal {
+	a, b, scale := align(r, other)
+	return Decimal{unscaled: a.Sub(a, b), scale: scale}
+}
+
+func (r Decimal) Mul(other Decimal) Decimal {
+	return Decimal{unscaled: new(big.Int).Mul(r.unscaled, other.unscaled), scale: r.scale + other.scale}
+}
+
+func (r Decimal) Neg() Decimal {
+	return Decimal{unscaled: new(big.Int).Neg(r.unscaled), scale: r.scale}
+}
+
+// powGuardDigits are kept beyond scale of Context by intermediate results of Pow
+const powGuardDigits = 8
+
+// Pow raises r to integer exponent by repeated squaring. The result is exact if it
+// fits into scale of context, otherwise it's rounded to it.
+func (r Decimal) Pow(exponent int64, context Context) (Decimal, error) {
+	if context.Rounding == 0 {
+		return Decimal{}, MissingRounding
+	}
+	n := exponent
+	if n < 0 {
+		n = -n
+	}
+	// intermediate results are rounded, so that their fractional digits don't grow with exponent
+	working := Context{Scale: context.Scale + powGuardDigits, Rounding: context.Rounding}
+	res, base := FromInt(big.NewInt(1)), r
+	for ; n > 0; n >>= 1 {
+		if n&1 == 1 {
+			res = res.Mul(base).roundTo(working)
+		}
+		if n > 1 {
+			base = base.Mul(base).roundTo(working)
+		}
+	}
+	if exponent < 0 {
+		return FromInt(big.NewInt(1)).Quo(res, context)
+	}
+	return res.roundTo(context), nil
+}
+
+// roundTo rounds r to scale of context if it has more fractional digits
+func (r Decimal) roundTo(context Context) Decimal {
+	if r.scale <= context.Scale {
+		return r
+	}
+	res, _ := r.Quo(FromInt(big.NewInt(1)), context)
+	return res
+}
+
+// Integer returns value of decimal if it has no fractional part
+func (r Decimal) Integer() (*big.Int, bool) {
+	scale := pow10(r.scale)
+	q, m := new(big.Int).QuoRem(r.unscaled, scale, new(big.Int))
+	return q, m.Sign() == 0
+}
+
+// Cmp returns -1, 0 or 1 when r is less than, equal to or greater than other
+func (r Decimal) Cmp(other Decimal) int {
+	a, b, _ := align(r, other)
+	return a.Cmp(b)
+}
+
+// Quo divides r by other rounding the result to context's scale, trailing zeros
+// beyond scale of the operands are dropped, so 10.00 / 4 is 2.50
+func (r Decimal) Quo(other Decimal, context Context) (Decimal, error) {
+	if other.unscaled.Sign() == 0 {
+		return Decimal{}, DivisionByZero
+	}
+	if context.Rounding == 0 {
+		return Decimal{}, MissingRounding
+	}
+
+	// r / other * 10^scale = r.unscaled * 10^(scale + other.scale - r.scale) / other.unscaled
+	num := new(big.Int).Set(r.unscaled)
+	den := new(big.Int).Set(other.unscaled)
+	if exp := context.Scale + other.scale - r.scale; exp >= 0 {
+		num.Mul(num, pow10(exp))
+	} else {
+		den.Mul(den, pow10(-exp))
+	}
+	quo, rem := new(big.Int).QuoRem(num, den, new(big.Int))
+	if rem.Sign() != 0 && roundsAway(context.Rounding, quo, rem, den, num.Sign()*den.Sign()) {
+		quo.Add(quo, big.NewInt(int64(num.Sign()*den.Sign())))
+	}
+
+	res := Decimal{unscaled: quo, scale: context.Scale}
+	minScale := r.scale
+	if other.scale > minScale {
+		minScale = other.scale
+	}
+	ten := big.NewInt(10)
+	for res.scale > minScale && new(big.Int).Rem(res.unscaled, ten).Sign() == 0 {
+		res.unscaled.Quo(res.unscaled, ten)
+		res.scale--
+	}
+	return res, nil
+}
+
+// roundsAway reports whether quotient truncated towards zero with non-zero
+// remainder has to be moved away from zero
+func roundsAway(mode RoundingMode, quo, rem, den *big.Int, sign int) bool {
+	switch mode {
+	case Down:
+		return false
+	case Up:
+		return true
+	case Floor:
+		return sign < 0
+	case Ceiling:
+		return sign > 0
+	}
+	half := new(big.Int).Abs(rem)
+	half.Mul(half, big.NewInt(2))
+	switch half.Cmp(new(big.Int).Abs(den)) {
+	case 1:
+		return true
+	case 0:
+		return mode == HalfUp || quo.Bit(0) == 1
+	}
+	return false
+}
+
+// align returns unscaled values of a and b with the same scale
+func align(a, b Decimal) (*big.Int, *big.Int, int) {
+	x, y := new(big.Int).Set(a.unscaled), new(big.Int).Set(b.unscaled)
+	switch {
+	case a.scale < b.scale:
+		x.Mul(x, pow10(b.scale-a.scale))
+		return x, y, b.scale
+	case a.scale > b.scale:
+		y.Mul(y, pow10(a.scale-b.scale))
+	}
+	return x, y, a.scale
+}
+
+func pow10(n int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
@@ -0,0 +1,74 @@
+package decimal
+
+import "testing"
+
+func TestQuoRoundsToContextScale(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		context  Context
+		expected string
+	}{
+		{"1", "3", Context{Scale: 4, Rounding: HalfEven}, "0.3333"},
+		{"2", "3", Context{Scale: 4, Rounding: HalfEven}, "0.6667"},
+		{"10.00", "4", Context{Scale: 16, Rounding: HalfEven}, "2.50"},
+		{"0.125", "1", Context{Scale: 2, Rounding: HalfEven}, "0.12"},
+		{"0.135", "1", Context{Scale: 2, Rounding: HalfEven}, "0.14"},
+		{"0.125", "1", Context{Scale: 2, Rounding: HalfUp}, "0.13"},
+		{"-0.125", "1", Context{Scale: 2, Rounding: HalfUp}, "-0.13"},
+		{"1", "3", Context{Scale: 2, Rounding: Up}, "0.34"},
+		{"-1", "3", Context{Scale: 2, Rounding: Down}, "-0.33"},
+		{"-1", "3", Context{Scale: 2, Rounding: Floor}, "-0.34"},
+		{"-1", "3", Context{Scale: 2, Rounding: Ceiling}, "-0.33"},
+		{"7", "2", Context{Scale: 0, Rounding: Floor}, "3"},
+	}
+	for _, test := range tests {
+		a, _ := Parse(test.a)
+		b, _ := Parse(test.b)
+		res, err := a.Quo(b, test.context)
+		if err != nil {
+			t.Errorf("%s / %s: unexpected error %v", test.a, test.b, err)
+			continue
+		}
+		if res.String() != test.expected {
+			t.Errorf("%s / %s with %+v: expected %s, got %s", test.a, test.b, test.context, test.expected, res)
+		}
+	}
+}
+
+func TestQuoFailsWithoutRoundingOrDivisor(t *testing.T) {
+	one, _ := Parse("1")
+	zero, _ := Parse("0.0")
+	if _, err := one.Quo(zero, DefaultContext); err != DivisionByZero {
+		t.Errorf("expected division by zero, got %v", err)
+	}
+	if _, err := one.Quo(one, Context{}); err != MissingRounding {
+		t.Errorf("expected missing rounding, got %v", err)
+	}
+}
+
+func TestPowIsExactUnlessResultExceedsScale(t *testing.T) {
+	tests := []struct {
+		base     string
+		exponent int64
+		expected string
+	}{
+		{"1.5", 2, "2.25"},
+		{"1.1", 3, "1.331"},
+		{"2", -2, "0.25"},
+		{"10", 20, "100000000000000000000"},
+		{"7", 0, "1"},
+		{"1.1", -5, "0.6209213230591552"},
+		{"1.0000001", 16000000, "4.9530320281525631"},
+	}
+	for _, test := range tests {
+		base, _ := Parse(test.base)
+		res, err := base.Pow(test.exponent, DefaultContext)
+		if err != nil {
+			t.Errorf("%s ** %d: unexpected error %v", test.base, test.exponent, err)
+			continue
+		}
+		if res.String() != test.expected {
+			t.Errorf("%s ** %d: expected %s, got %s", test.base, test.exponent, test.expected, res)
+		}
+	}
+}
@@ -1,8 +1,14 @@
 package internal
 
-import "gox/internal/scanning"
+import (
+	"errors"
+	"gox/internal/scanning"
+)
 
 type RuntimeError struct {
 	Error error
 	Token *scanning.Token
 }
+
+// InternalError marks failure of the interpreter itself rather than of the interpreted program
+var InternalError = errors.New("internal error")
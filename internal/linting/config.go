@@ -0,0 +1,73 @@
+package linting
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// names of rules, usable in config file and lint:ignore comments
+const (
+	UnusedVariable        = "unused-variable"
+	UnusedParameter       = "unused-parameter"
+	UnreachableCode       = "unreachable-code"
+	ShadowedVariable      = "shadowed-variable"
+	UndeclaredAssignment  = "undeclared-assignment"
+	AlwaysFalseComparison = "always-false-comparison"
+	EmptyBlock            = "empty-block"
+	InconsistentReturn    = "inconsistent-return"
+)
+
+var Rules = []string{
+	UnusedVariable,
+	UnusedParameter,
+	UnreachableCode,
+	ShadowedVariable,
+	UndeclaredAssignment,
+	AlwaysFalseComparison,
+	EmptyBlock,
+	InconsistentReturn,
+}
+
+// Config selects rules the linter reports
+type Config struct {
+	Rules map[string]bool `json:"rules"`
+}
+
+// DefaultConfig enables all rules
+func DefaultConfig() Config {
+	rules := make(map[string]bool)
+	for _, rule := range Rules {
+		rules[rule] = true
+	}
+	return Config{Rules: rules}
+}
+
+// LoadConfig reads JSON config file, e.g.
+//
+//	{"rules": {"shadowed-variable": false}}
+//
+// Rules not mentioned in the file stay enabled.
+func LoadConfig(path string) (Config, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return Config{}, err
+	}
+	var file Config
+	if err := json.Unmarshal(content, &file); err != nil {
+		return Config{}, fmt.Errorf("invalid lint config %s: %w", path, err)
+	}
+
+	config := DefaultConfig()
+	for rule, enabled := range file.Rules {
+		if _, ok := config.Rules[rule]; !ok {
+			return Config{}, fmt.Errorf("invalid lint config %s: unknown rule %s", path, rule)
+		}
+		config.Rules[rule] = enabled
+	}
+	return config, nil
+}
+
+func (r Config) enabled(rule string) bool {
+	return r.Rules[rule]
+}
@@ -0,0 +1,521 @@
+package linting
+
+import (
+	"fmt"
+	"gox/internal"
+	"gox/internal/ast"
+	"gox/internal/decimal"
+	"gox/internal/scanning"
+	"math/big"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var ignoreCommentPattern = regexp.MustCompile(`^//\s*lint:ignore\s+([\w,-]+)`)
+
+// Diagnostic is a single problem found by Linter
+type Diagnostic struct {
+	Rule    string
+	Message string
+	Token   *scanning.Token
+}
+
+type bindingKind int
+
+const (
+	variable bindingKind = iota
+	parameter
+	function
+)
+
+type binding struct {
+	name *scanning.Token
+	kind bindingKind
+	used bool
+}
+
+type scope struct {
+	bindings map[string]*binding
+	order    []*binding
+}
+
+// Linter reports suspicious constructs that are valid Lox but most likely bugs.
+// Unused globals are not reported as they may be meant for later REPL input.
+type Linter struct {
+	config      Config
+	globals     []string
+	scopes      []*scope
+	diagnostics []*Diagnostic
+}
+
+// NewLinter creates linter reporting rules enabled in config, globals are names
+// defined by the environment the program runs in, e.g. natives
+func NewLinter(config Config, globals []string) *Linter {
+	return &Linter{
+		config:  config,
+		globals: globals,
+	}
+}
+
+// Lint returns diagnostics for statements ordered by line. Diagnostics on lines
+// marked by "// lint:ignore rule" comment, or following such comment, are omitted.
+func (r *Linter) Lint(statements []*ast.Stmt, comments []scanning.Comment) []*Diagnostic {
+	r.diagnostics = make([]*Diagnostic, 0)
+	r.scopes = nil
+	r.beginScope()
+	for _, name := range r.globals {
+		r.declare(&scanning.Token{TokenType: scanning.IDENTIFIER, Lexeme: name}, function)
+	}
+	// globals may be referenced from functions declared before them
+	for _, stmt := range statements {
+		if stmt == nil {
+			continue
+		}
+		switch declaration := (*stmt).(type) {
+		case *ast.Function:
+			r.declare(declaration.Name, function)
+		case *ast.Var:
+			r.declare(declaration.Name, variable)
+		}
+	}
+	topLevel := make([]ast.Stmt, 0, len(statements))
+	for _, stmt := range statements {
+		if stmt != nil {
+			topLevel = append(topLevel, *stmt)
+		}
+	}
+	r.lintStatements(topLevel)
+	r.scopes = nil
+
+	return r.filter(comments)
+}
+
+// expressions
+func (r *Linter) VisitForLiteral(expr *ast.Literal) (any, *internal.RuntimeError) {
+	return nil, nil
+}
+
+func (r *Linter) VisitForUnary(expr *ast.Unary) (any, *internal.RuntimeError) {
+	r.lintExpr(*expr.Right)
+	return nil, nil
+}
+
+func (r *Linter) VisitForBinary(expr *ast.Binary) (any, *internal.RuntimeError) {
+	r.lintExpr(*expr.Left)
+	r.lintExpr(*expr.Right)
+
+	if expr.Operator.TokenType != scanning.EQUAL_EQUAL && expr.Operator.TokenType != scanning.BANG_EQUAL {
+		return nil, nil
+	}
+	left, leftIsLiteral := literal(*expr.Left)
+	right, rightIsLiteral := literal(*expr.Right)
+	if leftIsLiteral && rightIsLiteral && kindOf(left) != kindOf(right) {
+		result := expr.Operator.TokenType == scanning.BANG_EQUAL
+		r.report(AlwaysFalseComparison, expr.Operator, "comparison of %s with %s is always %t", kindOf(left), kindOf(right), result)
+	}
+	return nil, nil
+}
+
+func (r *Linter) VisitForGrouping(expr *ast.Grouping) (any, *internal.RuntimeError) {
+	r.lintExpr(*expr.Expression)
+	return nil, nil
+}
+
+func (r *Linter) VisitForVariableExpression(expr *ast.VarExpr) (any, *internal.RuntimeError) {
+	if b := r.lookup(expr.Name.Lexeme); b != nil {
+		b.used = true
+	}
+	return nil, nil
+}
+
+func (r *Linter) VisitForAssignExpression(expr *ast.Assign) (any, *internal.RuntimeError) {
+	r.lintExpr(expr.Value)
+	if r.lookup(expr.Name.Lexeme) == nil {
+		r.report(UndeclaredAssignment, expr.Name, "assignment to undeclared variable %s", expr.Name.Lexeme)
+	}
+	return nil, nil
+}
+
+func (r *Linter) VisitForLogical(expr *ast.Logical) (any, *internal.RuntimeError) {
+	r.lintExpr(expr.Left)
+	r.lintExpr(expr.Right)
+	return nil, nil
+}
+
+func (r *Linter) VisitForConditional(expr *ast.Conditional) (any, *internal.RuntimeError) {
+	r.lintExpr(expr.Condition)
+	r.lintExpr(expr.Then)
+	r.lintExpr(expr.Else)
+	return nil, nil
+}
+
+func (r *Linter) VisitForFunctionCall(expr *ast.Call) (any, *internal.RuntimeError) {
+	r.lintExpr(expr.Callee)
+	for _, param := range expr.Params {
+		r.lintExpr(param)
+	}
+	for _, arg := range expr.Named {
+		r.lintExpr(arg.Value)
+	}
+	return nil, nil
+}
+
+func (r *Linter) VisitForSpawn(expr *ast.Spawn) (any, *internal.RuntimeError) {
+	r.lintExpr(expr.Call)
+	return nil, nil
+}
+
+func (r *Linter) VisitForInterpolation(expr *ast.Interpolation) (any, *internal.RuntimeError) {
+	for _, part := range expr.Parts {
+		r.lintExpr(part)
+	}
+	return nil, nil
+}
+
+func (r *Linter) VisitForLambda(lambda *ast.Lambda) (any, *internal.RuntimeError) {
+	if r.lintFunction(lambda.Params, lambda.Body) {
+		r.report(InconsistentReturn, lambda.Keyword, "anonymous function returns value on some paths only")
+	}
+	return nil, nil
+}
+
+// statements
+func (r *Linter) VisitForExpression(stmt *ast.Expression) (internal.Completion, *internal.RuntimeError) {
+	r.lintExpr(*stmt.Expression)
+	return internal.NormalCompletion, nil
+}
+
+func (r *Linter) VisitForPrint(stmt *ast.Print) (internal.Completion, *internal.RuntimeError) {
+	r.lintExpr(*stmt.Expression)
+	return internal.NormalCompletion, nil
+}
+
+func (r *Linter) VisitForVar(stmt *ast.Var) (internal.Completion, *internal.RuntimeError) {
+	if stmt.Initializer != nil {
+		r.lintExpr(*stmt.Initializer)
+	}
+	r.declare(stmt.Name, variable)
+	return internal.NormalCompletion, nil
+}
+
+func (r *Linter) VisitForBlock(block *ast.Block) (internal.Completion, *internal.RuntimeError) {
+	if len(block.Statements) == 0 && block.Brace != nil {
+		r.report(EmptyBlock, block.Brace, "empty block")
+	}
+	r.beginScope()
+	r.lintStatements(block.Statements)
+	r.endScope()
+	return internal.NormalCompletion, nil
+}
+
+func (r *Linter) VisitForIf(ifStmt *ast.If) (internal.Completion, *internal.RuntimeError) {
+	r.lintExpr(ifStmt.Condition)
+	r.lintStmt(ifStmt.Then)
+	r.lintStmt(ifStmt.Else)
+	return internal.NormalCompletion, nil
+}
+
+func (r *Linter) VisitForMatch(stmt *ast.Match) (internal.Completion, *internal.RuntimeError) {
+	r.lintExpr(stmt.Value)
+	for _, c := range stmt.Cases {
+		r.beginScope()
+		for _, pattern := range c.Patterns {
+			r.lintExpr(pattern.Value)
+			r.lintExpr(pattern.To)
+			if pattern.Binding != nil && pattern.Binding.Lexeme != "_" {
+				r.declare(pattern.Binding, variable)
+			}
+		}
+		r.lintExpr(c.Guard)
+		r.lintStmt(c.Body)
+		r.endScope()
+	}
+	return internal.NormalCompletion, nil
+}
+
+func (r *Linter) VisitForForIn(stmt *ast.ForIn) (internal.Completion, *internal.RuntimeError) {
+	r.lintExpr(stmt.Iterable)
+	r.beginScope()
+	if stmt.Name.Lexeme != "_" {
+		r.declare(stmt.Name, variable)
+	}
+	r.lintStmt(stmt.Body)
+	r.endScope()
+	return internal.NormalCompletion, nil
+}
+
+func (r *Linter) VisitForWhile(while *ast.While) (internal.Completion, *internal.RuntimeError) {
+	r.lintExpr(while.Condition)
+	r.lintStmt(while.Statement)
+	r.lintExpr(while.Increment)
+	return internal.NormalCompletion, nil
+}
+
+func (r *Linter) VisitForFunction(fun *ast.Function) (internal.Completion, *internal.RuntimeError) {
+	r.declare(fun.Name, function)
+	if r.lintFunction(fun.Params, fun.Body) {
+		r.report(InconsistentReturn, fun.Name, "function %s returns value on some paths only", fun.Name.Lexeme)
+	}
+	return internal.NormalCompletion, nil
+}
+
+func (r *Linter) VisitForReturn(ret *ast.Return) (internal.Completion, *internal.RuntimeError) {
+	r.lintExpr(ret.Value)
+	return internal.NormalCompletion, nil
+}
+
+func (r *Linter) VisitForSelect(stmt *ast.Select) (internal.Completion, *internal.RuntimeError) {
+	for _, c := range stmt.Cases {
+		r.lintExpr(c.Channel)
+		r.lintExpr(c.Value)
+		r.beginScope()
+		if c.Binding != nil {
+			r.declare(c.Binding, variable)
+		}
+		r.lintStmt(c.Body)
+		r.endScope()
+	}
+	return internal.NormalCompletion, nil
+}
+
+func (r *Linter) VisitForYield(stmt *ast.Yield) (internal.Completion, *internal.RuntimeError) {
+	r.lintExpr(stmt.Value)
+	return internal.NormalCompletion, nil
+}
+
+func (r *Linter) VisitForBreak(stmt *ast.Break) (internal.Completion, *internal.RuntimeError) {
+	return internal.NormalCompletion, nil
+}
+
+func (r *Linter) VisitForContinue(stmt *ast.Continue) (internal.Completion, *internal.RuntimeError) {
+	return internal.NormalCompletion, nil
+}
+
+func (r *Linter) lintStatements(statements []ast.Stmt) {
+	for i, stmt := range statements {
+		r.lintStmt(stmt)
+		if i == len(statements)-1 {
+			continue
+		}
+		switch jump := stmt.(type) {
+		case *ast.Return:
+			r.report(UnreachableCode, jump.Name, "code after return is unreachable")
+		case *ast.Break:
+			r.report(UnreachableCode, jump.Keyword, "code after break is unreachable")
+		case *ast.Continue:
+			r.report(UnreachableCode, jump.Keyword, "code after continue is unreachable")
+		}
+	}
+}
+
+// lintFunction lints function body in a scope with its parameters and reports
+// whether the function returns inconsistently
+func (r *Linter) lintFunction(params []*ast.Param, body []ast.Stmt) bool {
+	r.beginScope()
+	for _, param := range params {
+		r.lintExpr(param.Default)
+		r.declare(param.Name, parameter)
+	}
+	r.lintStatements(body)
+	r.endScope()
+
+	withValue, withoutValue := returnKinds(body)
+	return withValue && (withoutValue || !alwaysReturns(body))
+}
+
+func (r *Linter) lintStmt(stmt ast.Stmt) {
+	if stmt == nil {
+		return
+	}
+	_, _ = stmt.Accept(r)
+}
+
+func (r *Linter) lintExpr(expr ast.Expr) {
+	if expr == nil {
+		return
+	}
+	_, _ = expr.Accept(r)
+}
+
+func (r *Linter) beginScope() {
+	r.scopes = append(r.scopes, &scope{bindings: make(map[string]*binding)})
+}
+
+func (r *Linter) endScope() {
+	current := r.scopes[len(r.scopes)-1]
+	for _, b := range current.order {
+		if b.used {
+			continue
+		}
+		switch b.kind {
+		case variable:
+			r.report(UnusedVariable, b.name, "variable %s is never used", b.name.Lexeme)
+		case parameter:
+			r.report(UnusedParameter, b.name, "parameter %s is never used", b.name.Lexeme)
+		}
+	}
+	r.scopes = r.scopes[:len(r.scopes)-1]
+}
+
+func (r *Linter) declare(name *scanning.Token, kind bindingKind) {
+	current := r.scopes[len(r.scopes)-1]
+	if previous, ok := current.bindings[name.Lexeme]; ok {
+		// redeclaration in the same scope, e.g. global declared by Lint upfront
+		previous.name = name
+		return
+	}
+	if len(r.scopes) > 1 && r.lookup(name.Lexeme) != nil {
+		r.report(ShadowedVariable, name, "declaration of %s shadows variable from outer scope", name.Lexeme)
+	}
+	b := &binding{name: name, kind: kind}
+	current.bindings[name.Lexeme] = b
+	current.order = append(current.order, b)
+}
+
+func (r *Linter) lookup(name string) *binding {
+	for i := len(r.scopes) - 1; i >= 0; i-- {
+		if b, ok := r.scopes[i].bindings[name]; ok {
+			return b
+		}
+	}
+	return nil
+}
+
+func (r *Linter) report(rule string, token *scanning.Token, format string, args ...any) {
+	if !r.config.enabled(rule) {
+		return
+	}
+	r.diagnostics = append(r.diagnostics, &Diagnostic{
+		Rule:    rule,
+		Message: fmt.Sprintf(format, args...),
+		Token:   token,
+	})
+}
+
+// filter drops diagnostics suppressed by comments and sorts the rest by line
+func (r *Linter) filter(comments []scanning.Comment) []*Diagnostic {
+	ignored := make(map[int]map[string]bool)
+	for _, comment := range comments {
+		match := ignoreCommentPattern.FindStringSubmatch(comment.Text)
+		if match == nil {
+			continue
+		}
+		for _, line := range []int{comment.Line, comment.Line + 1} {
+			if ignored[line] == nil {
+				ignored[line] = make(map[string]bool)
+			}
+			for _, rule := range strings.Split(match[1], ",") {
+				ignored[line][rule] = true
+			}
+		}
+	}
+
+	res := make([]*Diagnostic, 0, len(r.diagnostics))
+	for _, diagnostic := range r.diagnostics {
+		if !ignored[diagnostic.Token.Line][diagnostic.Rule] {
+			res = append(res, diagnostic)
+		}
+	}
+	sort.SliceStable(res, func(i, j int) bool {
+		return res[i].Token.Line < res[j].Token.Line
+	})
+	return res
+}
+
+// literal returns value of expression if it is a literal, possibly in parentheses
+func literal(expr ast.Expr) (any, bool) {
+	switch e := expr.(type) {
+	case *ast.Literal:
+		return e.Value, true
+	case *ast.Grouping:
+		return literal(*e.Expression)
+	}
+	return nil, false
+}
+
+func kindOf(value any) string {
+	switch value.(type) {
+	case nil:
+		return "nil"
+	case bool:
+		return "bool"
+	case int64, *big.Int, decimal.Decimal, float64:
+		return "number"
+	case string:
+		return "string"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+// returnKinds reports whether statements contain return with and without value,
+// nested function declarations are not inspected
+func returnKinds(statements []ast.Stmt) (withValue, withoutValue bool) {
+	for _, stmt := range statements {
+		var nested []ast.Stmt
+		switch s := stmt.(type) {
+		case *ast.Return:
+			if s.Value != nil {
+				withValue = true
+			} else {
+				withoutValue = true
+			}
+		case *ast.Block:
+			nested = s.Statements
+		case *ast.If:
+			nested = []ast.Stmt{s.Then, s.Else}
+		case *ast.While:
+			nested = []ast.Stmt{s.Statement}
+		case *ast.ForIn:
+			nested = []ast.Stmt{s.Body}
+		case *ast.Match:
+			for _, c := range s.Cases {
+				nested = append(nested, c.Body)
+			}
+		case *ast.Select:
+			for _, c := range s.Cases {
+				nested = append(nested, c.Body)
+			}
+		}
+		v, nv := returnKinds(nested)
+		withValue = withValue || v
+		withoutValue = withoutValue || nv
+	}
+	return withValue, withoutValue
+}
+
+// alwaysReturns reports whether execution of statements always ends with return
+func alwaysReturns(statements []ast.Stmt) bool {
+	for _, stmt := range statements {
+		switch s := stmt.(type) {
+		case *ast.Return:
+			return true
+		case *ast.Block:
+			if alwaysReturns(s.Statements) {
+				return true
+			}
+		case *ast.If:
+			if s.Else != nil && alwaysReturns([]ast.Stmt{s.Then}) && alwaysReturns([]ast.Stmt{s.Else}) {
+				return true
+			}
+		case *ast.Match:
+			// match without matching case fails, so only bodies of cases matter
+			returns := true
+			for _, c := range s.Cases {
+				returns = returns && alwaysReturns([]ast.Stmt{c.Body})
+			}
+			if returns {
+				return true
+			}
+		case *ast.Select:
+			returns := len(s.Cases) > 0
+			for _, c := range s.Cases {
+				returns = returns && alwaysReturns([]ast.Stmt{c.Body})
+			}
+			if returns {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,357 @@
+package optimizing
+
+import (
+	"gox/internal"
+	"gox/internal/ast"
+	"gox/internal/runtime"
+	"gox/internal/scanning"
+)
+
+// Optimizer rewrites statements into cheaper equivalent ones. It folds operations
+// on literals, short-circuits logical operators with literal left operand,
+// removes branches and loops whose condition is a literal and simplifies double
+// negation of boolean expressions. Operations that would fail are left intact so
+// the interpreter reports them as before.
+type Optimizer struct {
+	// replacement is the result of the last visited statement, nil removes it
+	replacement ast.Stmt
+}
+
+func NewOptimizer() *Optimizer {
+	return &Optimizer{}
+}
+
+// Optimize rewrites statements in place and returns the ones that are left
+func (r *Optimizer) Optimize(statements []*ast.Stmt) []*ast.Stmt {
+	res := make([]*ast.Stmt, 0, len(statements))
+	for _, stmt := range statements {
+		if stmt == nil || *stmt == nil {
+			res = append(res, stmt)
+			continue
+		}
+		optimized := r.optimizeStmt(*stmt)
+		if optimized != nil {
+			res = append(res, &optimized)
+		}
+	}
+	return res
+}
+
+// expressions
+func (r *Optimizer) VisitForLiteral(expr *ast.Literal) (any, *internal.RuntimeError) {
+	return expr, nil
+}
+
+func (r *Optimizer) VisitForUnary(expr *ast.Unary) (any, *internal.RuntimeError) {
+	right := r.optimizeExpr(*expr.Right)
+	expr.Right = &right
+
+	if _, ok := right.(*ast.Literal); ok {
+		return r.fold(expr), nil
+	}
+	if inner, ok := right.(*ast.Unary); ok && expr.Operator.TokenType == scanning.BANG &&
+		inner.Operator.TokenType == scanning.BANG && isBoolean(*inner.Right) {
+		return *inner.Right, nil
+	}
+	return expr, nil
+}
+
+func (r *Optimizer) VisitForBinary(expr *ast.Binary) (any, *internal.RuntimeError) {
+	left := r.optimizeExpr(*expr.Left)
+	right := r.optimizeExpr(*expr.Right)
+	expr.Left = &left
+	expr.Right = &right
+
+	_, leftIsLiteral := left.(*ast.Literal)
+	_, rightIsLiteral := right.(*ast.Literal)
+	if leftIsLiteral && rightIsLiteral {
+		return r.fold(expr), nil
+	}
+	return expr, nil
+}
+
+func (r *Optimizer) VisitForGrouping(expr *ast.Grouping) (any, *internal.RuntimeError) {
+	inner := r.optimizeExpr(*expr.Expression)
+	if _, ok := inner.(*ast.Literal); ok {
+		return inner, nil
+	}
+	expr.Expression = &inner
+	return expr, nil
+}
+
+func (r *Optimizer) VisitForVariableExpression(expr *ast.VarExpr) (any, *internal.RuntimeError) {
+	return expr, nil
+}
+
+func (r *Optimizer) VisitForAssignExpression(expr *ast.Assign) (any, *internal.RuntimeError) {
+	expr.Value = r.optimizeExpr(expr.Value)
+	return expr, nil
+}
+
+func (r *Optimizer) VisitForLogical(expr *ast.Logical) (any, *internal.RuntimeError) {
+	expr.Left = r.optimizeExpr(expr.Left)
+	expr.Right = r.optimizeExpr(expr.Right)
+
+	left, ok := expr.Left.(*ast.Literal)
+	if !ok {
+		return expr, nil
+	}
+	if expr.Operator.TokenType == scanning.QUESTION_QUESTION {
+		if left.Value != nil {
+			return left, nil
+		}
+		return expr.Right, nil
+	}
+	truthy := runtime.IsTruthy(left.Value)
+	if expr.Operator.TokenType == scanning.OR && truthy || expr.Operator.TokenType == scanning.AND && !truthy {
+		return left, nil
+	}
+	return expr.Right, nil
+}
+
+func (r *Optimizer) VisitForConditional(expr *ast.Conditional) (any, *internal.RuntimeError) {
+	expr.Condition = r.optimizeExpr(expr.Condition)
+	expr.Then = r.optimizeExpr(expr.Then)
+	expr.Else = r.optimizeExpr(expr.Else)
+
+	condition, ok := expr.Condition.(*ast.Literal)
+	if !ok {
+		return expr, nil
+	}
+	if runtime.IsTruthy(condition.Value) {
+		return expr.Then, nil
+	}
+	return expr.Else, nil
+}
+
+func (r *Optimizer) VisitForFunctionCall(expr *ast.Call) (any, *internal.RuntimeError) {
+	expr.Callee = r.optimizeExpr(expr.Callee)
+	for i, param := range expr.Params {
+		expr.Params[i] = r.optimizeExpr(param)
+	}
+	for _, arg := range expr.Named {
+		arg.Value = r.optimizeExpr(arg.Value)
+	}
+	return expr, nil
+}
+
+func (r *Optimizer) VisitForSpawn(expr *ast.Spawn) (any, *internal.RuntimeError) {
+	r.optimizeExpr(expr.Call)
+	return expr, nil
+}
+
+func (r *Optimizer) VisitForInterpolation(expr *ast.Interpolation) (any, *internal.RuntimeError) {
+	constant := true
+	for i, part := range expr.Parts {
+		expr.Parts[i] = r.optimizeExpr(part)
+		_, isLiteral := expr.Parts[i].(*ast.Literal)
+		constant = constant && isLiteral
+	}
+	if constant {
+		return r.fold(expr), nil
+	}
+	return expr, nil
+}
+
+func (r *Optimizer) VisitForLambda(expr *ast.Lambda) (any, *internal.RuntimeError) {
+	r.optimizeParams(expr.Params)
+	expr.Body = r.optimizeStatements(expr.Body)
+	return expr, nil
+}
+
+// statements
+func (r *Optimizer) VisitForExpression(stmt *ast.Expression) (internal.Completion, *internal.RuntimeError) {
+	expr := r.optimizeExpr(*stmt.Expression)
+	stmt.Expression = &expr
+	r.replacement = stmt
+	return internal.NormalCompletion, nil
+}
+
+func (r *Optimizer) VisitForPrint(stmt *ast.Print) (internal.Completion, *internal.RuntimeError) {
+	expr := r.optimizeExpr(*stmt.Expression)
+	stmt.Expression = &expr
+	r.replacement = stmt
+	return internal.NormalCompletion, nil
+}
+
+func (r *Optimizer) VisitForVar(stmt *ast.Var) (internal.Completion, *internal.RuntimeError) {
+	if stmt.Initializer != nil {
+		initializer := r.optimizeExpr(*stmt.Initializer)
+		stmt.Initializer = &initializer
+	}
+	r.replacement = stmt
+	return internal.NormalCompletion, nil
+}
+
+func (r *Optimizer) VisitForBlock(block *ast.Block) (internal.Completion, *internal.RuntimeError) {
+	block.Statements = r.optimizeStatements(block.Statements)
+	r.replacement = block
+	return internal.NormalCompletion, nil
+}
+
+func (r *Optimizer) VisitForIf(ifStmt *ast.If) (internal.Completion, *internal.RuntimeError) {
+	ifStmt.Condition = r.optimizeExpr(ifStmt.Condition)
+	then := r.optimizeBody(ifStmt.Then)
+	var elseStmt ast.Stmt
+	if ifStmt.Else != nil {
+		elseStmt = r.optimizeStmt(ifStmt.Else)
+	}
+
+	condition, ok := ifStmt.Condition.(*ast.Literal)
+	switch {
+	case !ok:
+		ifStmt.Then = then
+		ifStmt.Else = elseStmt
+		r.replacement = ifStmt
+	case runtime.IsTruthy(condition.Value):
+		r.replacement = then
+	default:
+		r.replacement = elseStmt
+	}
+	return internal.NormalCompletion, nil
+}
+
+func (r *Optimizer) VisitForMatch(stmt *ast.Match) (internal.Completion, *internal.RuntimeError) {
+	stmt.Value = r.optimizeExpr(stmt.Value)
+	for _, c := range stmt.Cases {
+		for _, pattern := range c.Patterns {
+			pattern.Value = r.optimizeExpr(pattern.Value)
+			pattern.To = r.optimizeExpr(pattern.To)
+		}
+		c.Guard = r.optimizeExpr(c.Guard)
+		c.Body = r.optimizeBody(c.Body)
+	}
+	r.replacement = stmt
+	return internal.NormalCompletion, nil
+}
+
+func (r *Optimizer) VisitForForIn(stmt *ast.ForIn) (internal.Completion, *internal.RuntimeError) {
+	stmt.Iterable = r.optimizeExpr(stmt.Iterable)
+	stmt.Body = r.optimizeBody(stmt.Body)
+	r.replacement = stmt
+	return internal.NormalCompletion, nil
+}
+
+func (r *Optimizer) VisitForWhile(while *ast.While) (internal.Completion, *internal.RuntimeError) {
+	while.Condition = r.optimizeExpr(while.Condition)
+	while.Statement = r.optimizeBody(while.Statement)
+	while.Increment = r.optimizeExpr(while.Increment)
+
+	if condition, ok := while.Condition.(*ast.Literal); ok && !runtime.IsTruthy(condition.Value) {
+		r.replacement = nil
+	} else {
+		r.replacement = while
+	}
+	return internal.NormalCompletion, nil
+}
+
+func (r *Optimizer) VisitForFunction(function *ast.Function) (internal.Completion, *internal.RuntimeError) {
+	r.optimizeParams(function.Params)
+	function.Body = r.optimizeStatements(function.Body)
+	r.replacement = function
+	return internal.NormalCompletion, nil
+}
+
+func (r *Optimizer) VisitForSelect(stmt *ast.Select) (internal.Completion, *internal.RuntimeError) {
+	for _, c := range stmt.Cases {
+		c.Channel = r.optimizeExpr(c.Channel)
+		c.Value = r.optimizeExpr(c.Value)
+		c.Body = r.optimizeBody(c.Body)
+	}
+	r.replacement = stmt
+	return internal.NormalCompletion, nil
+}
+
+func (r *Optimizer) VisitForYield(stmt *ast.Yield) (internal.Completion, *internal.RuntimeError) {
+	stmt.Value = r.optimizeExpr(stmt.Value)
+	r.replacement = stmt
+	return internal.NormalCompletion, nil
+}
+
+func (r *Optimizer) VisitForReturn(ret *ast.Return) (internal.Completion, *internal.RuntimeError) {
+	if ret.Value != nil {
+		ret.Value = r.optimizeExpr(ret.Value)
+	}
+	r.replacement = ret
+	return internal.NormalCompletion, nil
+}
+
+func (r *Optimizer) VisitForBreak(stmt *ast.Break) (internal.Completion, *internal.RuntimeError) {
+	r.replacement = stmt
+	return internal.NormalCompletion, nil
+}
+
+func (r *Optimizer) VisitForContinue(stmt *ast.Continue) (internal.Completion, *internal.RuntimeError) {
+	r.replacement = stmt
+	return internal.NormalCompletion, nil
+}
+
+func (r *Optimizer) optimizeExpr(expr ast.Expr) ast.Expr {
+	if expr == nil {
+		return nil
+	}
+	res, _ := expr.Accept(r)
+	return res.(ast.Expr)
+}
+
+// optimizeStmt returns optimized statement or nil if the statement has no effect
+func (r *Optimizer) optimizeStmt(stmt ast.Stmt) ast.Stmt {
+	if stmt == nil {
+		return nil
+	}
+	_, _ = stmt.Accept(r)
+	return r.replacement
+}
+
+// optimizeBody optimizes statement which has to be present, e.g. body of a loop
+func (r *Optimizer) optimizeBody(stmt ast.Stmt) ast.Stmt {
+	if optimized := r.optimizeStmt(stmt); optimized != nil {
+		return optimized
+	}
+	return &ast.Block{Statements: make([]ast.Stmt, 0)}
+}
+
+func (r *Optimizer) optimizeStatements(statements []ast.Stmt) []ast.Stmt {
+	res := make([]ast.Stmt, 0, len(statements))
+	for _, stmt := range statements {
+		if optimized := r.optimizeStmt(stmt); optimized != nil {
+			res = append(res, optimized)
+		}
+	}
+	return res
+}
+
+func (r *Optimizer) optimizeParams(params []*ast.Param) {
+	for _, param := range params {
+		param.Default = r.optimizeExpr(param.Default)
+	}
+}
+
+// fold replaces expression with literal of its value unless it fails at runtime
+func (r *Optimizer) fold(expr ast.Expr) ast.Expr {
+	value, ok := runtime.EvaluateConstant(expr)
+	if !ok {
+		return expr
+	}
+	return &ast.Literal{Value: value}
+}
+
+// isBoolean reports whether expression always evaluates to bool
+func isBoolean(expr ast.Expr) bool {
+	switch e := expr.(type) {
+	case *ast.Literal:
+		_, ok := e.Value.(bool)
+		return ok
+	case *ast.Grouping:
+		return isBoolean(*e.Expression)
+	case *ast.Unary:
+		return e.Operator.TokenType == scanning.BANG
+	case *ast.Binary:
+		switch e.Operator.TokenType {
+		case scanning.EQUAL_EQUAL, scanning.BANG_EQUAL, scanning.GREATER, scanning.GREATER_EQUAL,
+			scanning.LESS, scanning.LESS_EQUAL:
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,88 @@
+package optimizing
+
+import (
+	"gox/internal/ast"
+	"gox/internal/parsing"
+	"gox/internal/scanning"
+	"testing"
+)
+
+func optimize(t *testing.T, source string) []*ast.Stmt {
+	tokens, syntaxErr := scanning.NewLexer(source).ScanTokens()
+	if syntaxErr != nil {
+		t.Fatal(syntaxErr)
+	}
+	statements, parseErr := parsing.NewParser(tokens).Parse()
+	if parseErr != nil {
+		t.Fatal(parseErr)
+	}
+	return NewOptimizer().Optimize(statements)
+}
+
+func TestFoldsConstantExpressions(t *testing.T) {
+	tests := []struct {
+		source   string
+		expected any
+	}{
+		{"print 2 * 3.5;", 7.0},
+		{"print (1 + 2) * 4;", int64(12)},
+		{`print "a" + "b";`, "ab"},
+		{"print 1 < 2;", true},
+		{"print !nil;", true},
+		{"print -(-3);", int64(3)},
+		{`print nil or "x";`, "x"},
+		{`print false and 1;`, false},
+		{`print "n: ${1 + 1}";`, "n: 2"},
+		{`print 1 < 2 ? "yes" : "no";`, "yes"},
+		{`print nil ?? 0;`, int64(0)},
+		{`print false ?? true;`, false},
+	}
+	for _, test := range tests {
+		statements := optimize(t, test.source)
+		if len(statements) != 1 {
+			t.Fatalf("%s: expected 1 statement, got %d", test.source, len(statements))
+		}
+		literal, ok := (*(*statements[0]).(*ast.Print).Expression).(*ast.Literal)
+		if !ok {
+			t.Errorf("%s: expression was not folded", test.source)
+			continue
+		}
+		if literal.Value != test.expected {
+			t.Errorf("%s: expected %v, got %v", test.source, test.expected, literal.Value)
+		}
+	}
+}
+
+func TestKeepsFailingAndVariableExpressions(t *testing.T) {
+	for _, source := range []string{`print 1 - "a";`, "var a = 1; print a + 1;", `print -"a";`} {
+		statements := optimize(t, source)
+		last := *statements[len(statements)-1]
+		if _, ok := (*last.(*ast.Print).Expression).(*ast.Literal); ok {
+			t.Errorf("%s: expression must not be folded", source)
+		}
+	}
+}
+
+func TestSimplifiesDoubleNegation(t *testing.T) {
+	statements := optimize(t, "var a = 1; print !!(a < 2);")
+	expr := *(*statements[1]).(*ast.Print).Expression
+	if grouping, ok := expr.(*ast.Grouping); !ok {
+		t.Errorf("expected double negation to be removed, got %T", expr)
+	} else if _, ok := (*grouping.Expression).(*ast.Binary); !ok {
+		t.Errorf("expected comparison, got %T", *grouping.Expression)
+	}
+}
+
+func TestRemovesDeadCode(t *testing.T) {
+	statements := optimize(t, `if (false) print 1; while (nil) print 2; if (true) print 3; else print 4;`)
+	if len(statements) != 1 {
+		t.Fatalf("expected 1 statement, got %d", len(statements))
+	}
+	print, ok := (*statements[0]).(*ast.Print)
+	if !ok {
+		t.Fatalf("expected then branch to replace if, got %T", *statements[0])
+	}
+	if literal := (*print.Expression).(*ast.Literal); literal.Value != int64(3) {
+		t.Errorf("expected then branch, got print %v", literal.Value)
+	}
+}
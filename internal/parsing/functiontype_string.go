@@ -10,11 +10,12 @@ func _() {
 	var x [1]struct{}
 	_ = x[FUNCTION-0]
 	_ = x[METHOD-1]
+	_ = x[LAMBDA-2]
 }
 
-const _functionType_name = "FUNCTIONMETHOD"
+const _functionType_name = "FUNCTIONMETHODLAMBDA"
 
-var _functionType_index = [...]uint8{0, 8, 14}
+var _functionType_index = [...]uint8{0, 8, 14, 20}
 
 func (i functionType) String() string {
 	if i < 0 || i >= functionType(len(_functionType_index)-1) {
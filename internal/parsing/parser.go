@@ -29,6 +29,38 @@ const (
 	expectedRightParentAfterParamListMsg  = "expected ) after param list"
 	expectedLeftBraceBeforeFuncBody       = "expected { brace before %s body"
 	missingSemicolonAfterReturnMsg        = "missing ';' after return statement"
+	expectedLeftParenAfterFunMsg          = "expected ( after 'fun'"
+	expectedExpressionMsg                 = "expected expression"
+	restParamNotLastMsg                   = "rest parameter must be the last one"
+	requiredParamAfterOptionalMsg         = "parameter without default value can't follow one with default value"
+	positionalAfterNamedArgMsg            = "positional argument can't follow named arguments"
+	expectedInterpolationEndMsg           = "expected } after interpolated expression"
+	expectedTypeNameMsg                   = "expected type name after ':'"
+	missingSemicolonAfterBreakMsg         = "expected ; after 'break'"
+	missingSemicolonAfterContinueMsg      = "expected ; after 'continue'"
+	returnOutsideFunctionMsg              = "can't return from top-level code"
+	breakOutsideLoopMsg                   = "can't use 'break' outside of a loop"
+	continueOutsideLoopMsg                = "can't use 'continue' outside of a loop"
+	expectedColonInConditionalMsg         = "expected : after then branch of conditional expression"
+	missingLeftParenAfterMatchMsg         = "expected ( after 'match'"
+	missingRightParenAfterMatchMsg        = "expected ) after matched value"
+	missingLeftBraceAfterMatchMsg         = "expected { before match cases"
+	expectedCaseMsg                       = "expected 'case'"
+	expectedArrowInCaseMsg                = "expected => before case body"
+	bindingInAlternativesMsg              = "binding pattern can't be combined with other patterns"
+	expectedPatternMsg                    = "expected pattern"
+	yieldOutsideGeneratorMsg              = "can't use 'yield' outside of a generator"
+	missingSemicolonAfterYieldMsg         = "expected ; after yielded value"
+	returnValueFromGeneratorMsg           = "can't return a value from a generator"
+	generatorReturnTypeMsg                = "generator can't declare return type"
+	expectedCallAfterSpawnMsg             = "expected function call after 'spawn'"
+	missingLeftBraceAfterSelectMsg        = "expected { after 'select'"
+	expectedSelectOperationMsg            = "expected recv or send in select case"
+	expectedLeftParenInSelectMsg          = "expected ( after %s"
+	expectedCommaAfterChannelMsg          = "expected , after channel"
+	expectedRightParenInSelectMsg         = "expected ) after %s arguments"
+	duplicateDefaultCaseMsg               = "select can have only one default case"
+	emptySelectMsg                        = "select must have at least one case"
 )
 
 type functionType int
@@ -36,12 +68,24 @@ type functionType int
 const (
 	FUNCTION functionType = iota
 	METHOD
+	LAMBDA
 )
 
 var (
 	invalidAssignmentTarget = errors.New("invalid assignment target")
 )
 
+// compoundAssignments maps compound assignment operators to the binary operators they apply
+var compoundAssignments = map[scanning.TokenType]scanning.TokenType{
+	scanning.PLUS_EQUAL:    scanning.PLUS,
+	scanning.MINUS_EQUAL:   scanning.MINUS,
+	scanning.STAR_EQUAL:    scanning.STAR,
+	scanning.SLASH_EQUAL:   scanning.SLASH,
+	scanning.PERCENT_EQUAL: scanning.PERCENT,
+	scanning.PLUS_PLUS:     scanning.PLUS,
+	scanning.MINUS_MINUS:   scanning.MINUS,
+}
+
 type ParseError struct {
 	TokenError
 }
@@ -53,8 +97,11 @@ type TokenError struct {
 
 // TODO: write tests
 type Parser struct {
-	tokens  []scanning.Token
-	current int
+	tokens        []scanning.Token
+	current       int
+	loopDepth     int  // number of loops enclosing current statement within current function
+	functionDepth int  // number of functions enclosing current statement
+	generator     bool // innermost function enclosing current statement is a generator
 }
 
 func NewParser(tokens []scanning.Token) *Parser {
@@ -82,27 +129,16 @@ func (r *Parser) Parse() ([]*ast2.Stmt, *ParseError) {
 
 func (r *Parser) declaration() (ast2.Stmt, *TokenError) {
 	if r.match(scanning.VAR) {
-		declaration, tokenError := r.varDeclaration()
-		if tokenError != nil {
-			r.synchronize()
-			// TODO: really dont return tokenErr?
-			return nil, nil
-		}
-		return declaration, tokenError
-	} else if r.match(scanning.FUN) {
-		declaration, tokenError := r.function(FUNCTION)
-		if tokenError != nil {
-			r.synchronize()
-			// TODO: really dont return tokenErr?
-			return nil, nil
-		}
-		return declaration, tokenError
+		return r.varDeclaration()
+	} else if r.check(scanning.FUN) && (r.checkNext(scanning.IDENTIFIER) || r.checkNext(scanning.STAR)) {
+		r.advance()
+		return r.function(FUNCTION, r.match(scanning.STAR))
 	} else {
 		return r.statement()
 	}
 }
 
-func (r *Parser) function(fType functionType) (ast2.Stmt, *TokenError) {
+func (r *Parser) function(fType functionType, generator bool) (ast2.Stmt, *TokenError) {
 	funName, tokenError := r.consume(scanning.IDENTIFIER, fmt.Sprintf(expectedFuncNameMsg, fType.String()))
 	if tokenError != nil {
 		return nil, tokenError
@@ -112,39 +148,226 @@ func (r *Parser) function(fType functionType) (ast2.Stmt, *TokenError) {
 	if tokenError != nil {
 		return nil, tokenError
 	}
-	var params = make([]*scanning.Token, 0)
-	if !r.check(scanning.RIGHT_PAREN) {
-		param, tokenError := r.consume(scanning.IDENTIFIER, expectedParamNameMsg)
+	params, returnType, tokenError := r.signature()
+	if tokenError != nil {
+		return nil, tokenError
+	}
+	if tokenError := checkParams(params); tokenError != nil {
+		return nil, tokenError
+	}
+	if generator && returnType != nil {
+		return nil, &TokenError{error: errors.New(generatorReturnTypeMsg), Token: returnType}
+	}
+	_, tokenError = r.consume(scanning.LEFT_BRACE, fmt.Sprintf(expectedLeftBraceBeforeFuncBody, fType.String()))
+	if tokenError != nil {
+		return nil, tokenError
+	}
+	body, tokenError := r.functionBody(generator)
+	if tokenError != nil {
+		return nil, tokenError
+	}
+
+	return &ast2.Function{
+		Name:       funName,
+		Params:     params,
+		ReturnType: returnType,
+		Body:       body,
+		Generator:  generator,
+	}, nil
+
+}
+
+// interpolation parses string literal with interpolated expressions, the first
+// INTERPOLATION token is already consumed
+func (r *Parser) interpolation() (ast2.Expr, *TokenError) {
+	quote := r.previous()
+	parts := []ast2.Expr{&ast2.Literal{Value: quote.Literal}}
+	for {
+		expr, tokenError := r.expression()
 		if tokenError != nil {
 			return nil, tokenError
 		}
+		parts = append(parts, expr)
+		if r.match(scanning.INTERPOLATION) {
+			parts = append(parts, &ast2.Literal{Value: r.previous().Literal})
+			continue
+		}
+		end, tokenError := r.consume(scanning.STRING, expectedInterpolationEndMsg)
+		if tokenError != nil {
+			return nil, tokenError
+		}
+		parts = append(parts, &ast2.Literal{Value: end.Literal})
+		return &ast2.Interpolation{Quote: quote, Parts: parts}, nil
+	}
+}
+
+// lambda parses anonymous function expression, 'fun' keyword is already consumed
+func (r *Parser) lambda() (ast2.Expr, *TokenError) {
+	keyword := r.previous()
+	_, tokenError := r.consume(scanning.LEFT_PAREN, expectedLeftParenAfterFunMsg)
+	if tokenError != nil {
+		return nil, tokenError
+	}
+	params, returnType, tokenError := r.signature()
+	if tokenError != nil {
+		return nil, tokenError
+	}
+	if tokenError := checkParams(params); tokenError != nil {
+		return nil, tokenError
+	}
+	_, tokenError = r.consume(scanning.LEFT_BRACE, fmt.Sprintf(expectedLeftBraceBeforeFuncBody, LAMBDA.String()))
+	if tokenError != nil {
+		return nil, tokenError
+	}
+	body, tokenError := r.functionBody(false)
+	if tokenError != nil {
+		return nil, tokenError
+	}
+
+	return &ast2.Lambda{
+		Keyword:    keyword,
+		Params:     params,
+		ReturnType: returnType,
+		Body:       body,
+	}, nil
+}
+
+// arrowFunction parses "(params) => body" where body is either block or single
+// expression, opening paren is already consumed. If following tokens don't form
+// arrow function, nothing is consumed and nil is returned.
+func (r *Parser) arrowFunction() (ast2.Expr, *TokenError) {
+	start := r.current
+	params, returnType, tokenError := r.signature()
+	if tokenError != nil || !r.match(scanning.ARROW) {
+		r.current = start
+		return nil, nil
+	}
+	arrow := r.previous()
+	if tokenError := checkParams(params); tokenError != nil {
+		return nil, tokenError
+	}
+
+	var body []ast2.Stmt
+	if r.match(scanning.LEFT_BRACE) {
+		body, tokenError = r.functionBody(false)
+		if tokenError != nil {
+			return nil, tokenError
+		}
+	} else {
+		value, tokenError := r.expression()
+		if tokenError != nil {
+			return nil, tokenError
+		}
+		body = []ast2.Stmt{&ast2.Return{Name: arrow, Value: value}}
+	}
+
+	return &ast2.Lambda{
+		Keyword:    arrow,
+		Params:     params,
+		ReturnType: returnType,
+		Body:       body,
+	}, nil
+}
+
+// signature parses parameter list and optional return type, opening paren is already consumed
+func (r *Parser) signature() ([]*ast2.Param, *scanning.Token, *TokenError) {
+	var params = make([]*ast2.Param, 0)
+	if !r.check(scanning.RIGHT_PAREN) {
+		param, tokenError := r.param()
+		if tokenError != nil {
+			return nil, nil, tokenError
+		}
 		params = append(params, param)
 		for r.match(scanning.COMMA) {
-			param, tokenError := r.consume(scanning.IDENTIFIER, expectedParamNameMsg)
+			param, tokenError := r.param()
 			if tokenError != nil {
-				return nil, tokenError
+				return nil, nil, tokenError
 			}
 			params = append(params, param)
 		}
-		_, tokenError = r.consume(scanning.RIGHT_PAREN, expectedRightParentAfterParamListMsg)
-
 	}
-	_, tokenError = r.consume(scanning.LEFT_BRACE, fmt.Sprintf(expectedLeftBraceBeforeFuncBody, fType.String()))
+	_, tokenError := r.consume(scanning.RIGHT_PAREN, expectedRightParentAfterParamListMsg)
 	if tokenError != nil {
-		return nil, tokenError
+		return nil, nil, tokenError
+	}
+
+	returnType, tokenError := r.optionalTypeAnnotation()
+	if tokenError != nil {
+		return nil, nil, tokenError
 	}
+	return params, returnType, nil
+}
 
+// functionBody parses statements of function body, opening brace is already consumed
+func (r *Parser) functionBody(generator bool) ([]ast2.Stmt, *TokenError) {
+	enclosingLoopDepth, enclosingGenerator := r.loopDepth, r.generator
+	r.loopDepth = 0
+	r.generator = generator
+	r.functionDepth++
 	body, tokenError := r.block()
+	r.functionDepth--
+	r.loopDepth, r.generator = enclosingLoopDepth, enclosingGenerator
 	if tokenError != nil {
 		return nil, tokenError
 	}
+	return body.Statements, nil
+}
 
-	return &ast2.Function{
-		Name:   funName,
-		Params: params,
-		Body:   body.Statements,
+// param parses "name[: type] [= default]" or "...name[: type]"
+func (r *Parser) param() (*ast2.Param, *TokenError) {
+	rest := r.match(scanning.ELLIPSIS)
+	name, tokenError := r.consume(scanning.IDENTIFIER, expectedParamNameMsg)
+	if tokenError != nil {
+		return nil, tokenError
+	}
+	paramType, tokenError := r.optionalTypeAnnotation()
+	if tokenError != nil {
+		return nil, tokenError
+	}
+	var defaultValue ast2.Expr
+	if !rest && r.match(scanning.EQUAL) {
+		defaultValue, tokenError = r.expression()
+		if tokenError != nil {
+			return nil, tokenError
+		}
+	}
+	return &ast2.Param{
+		Name:    name,
+		Type:    paramType,
+		Default: defaultValue,
+		Rest:    rest,
 	}, nil
+}
 
+// checkParams verifies that parameters with default value follow the required
+// ones and rest parameter comes last
+func checkParams(params []*ast2.Param) *TokenError {
+	optional := false
+	for i, param := range params {
+		switch {
+		case param.Rest && i != len(params)-1:
+			return &TokenError{error: errors.New(restParamNotLastMsg), Token: param.Name}
+		case param.Default != nil:
+			optional = true
+		case optional && !param.Rest:
+			return &TokenError{error: errors.New(requiredParamAfterOptionalMsg), Token: param.Name}
+		}
+	}
+	return nil
+}
+
+// optionalTypeAnnotation parses ": type" if present, returns nil otherwise
+func (r *Parser) optionalTypeAnnotation() (*scanning.Token, *TokenError) {
+	if !r.match(scanning.COLON) {
+		return nil, nil
+	}
+	if r.match(scanning.IDENTIFIER, scanning.NIL, scanning.FUN) {
+		return r.previous(), nil
+	}
+	return nil, &TokenError{
+		error: errors.New(expectedTypeNameMsg),
+		Token: r.peek(),
+	}
 }
 
 func (r *Parser) varDeclaration() (ast2.Stmt, *TokenError) {
@@ -152,6 +375,10 @@ func (r *Parser) varDeclaration() (ast2.Stmt, *TokenError) {
 	if tokenError != nil {
 		return nil, tokenError
 	}
+	varType, tokenError := r.optionalTypeAnnotation()
+	if tokenError != nil {
+		return nil, tokenError
+	}
 
 	if r.match(scanning.EQUAL) {
 		initializer, err := r.expression()
@@ -162,12 +389,14 @@ func (r *Parser) varDeclaration() (ast2.Stmt, *TokenError) {
 
 		return &ast2.Var{
 			Name:        identifier,
+			Type:        varType,
 			Initializer: &initializer,
 		}, tokenError
 	}
 	_, tokenError = r.consume(scanning.SEMICOLON, expectedSemicolonMsg)
 	return &ast2.Var{
 		Name: identifier,
+		Type: varType,
 	}, tokenError
 }
 
@@ -184,9 +413,24 @@ func (r *Parser) statement() (ast2.Stmt, *TokenError) {
 	if r.match(scanning.RETURN) {
 		return r.returnStatement()
 	}
+	if r.match(scanning.BREAK) {
+		return r.loopJumpStatement()
+	}
+	if r.match(scanning.CONTINUE) {
+		return r.loopJumpStatement()
+	}
 	if r.match(scanning.WHILE) {
 		return r.whileStatement()
 	}
+	if r.match(scanning.MATCH) {
+		return r.matchStatement()
+	}
+	if r.match(scanning.YIELD) {
+		return r.yieldStatement()
+	}
+	if r.match(scanning.SELECT) {
+		return r.selectStatement()
+	}
 	if r.match(scanning.LEFT_BRACE) {
 		return r.block()
 	}
@@ -198,76 +442,144 @@ func (r *Parser) expression() (ast2.Expr, *TokenError) {
 	return r.assignment()
 }
 
-func (r *Parser) equality() (ast2.Expr, *TokenError) {
-	expr, err := r.comparison()
-
-	for r.match(scanning.EQUAL_EQUAL, scanning.BANG_EQUAL) {
-		operator := r.previous()
-		right, err := r.comparison()
-		return &ast2.Binary{
-			Left:     &expr,
-			Operator: operator,
-			Right:    &right,
-		}, err
+// rangeExpr parses from..to and from..=to, which can't be chained
+func (r *Parser) rangeExpr() (ast2.Expr, *TokenError) {
+	expr, err := r.bitwiseOr()
+	if err != nil || !r.match(scanning.DOT_DOT, scanning.DOT_DOT_EQUAL) {
+		return expr, err
+	}
+	operator := r.previous()
+	right, err := r.bitwiseOr()
+	if err != nil {
+		return nil, err
 	}
-	return expr, err
+	return &ast2.Binary{
+		Left:     &expr,
+		Operator: operator,
+		Right:    &right,
+	}, nil
+}
+
+func (r *Parser) equality() (ast2.Expr, *TokenError) {
+	return r.binary(r.comparison, scanning.EQUAL_EQUAL, scanning.BANG_EQUAL)
 }
 
 func (r *Parser) comparison() (ast2.Expr, *TokenError) {
-	expr, err := r.term()
+	return r.binary(r.rangeExpr, scanning.GREATER, scanning.GREATER_EQUAL, scanning.LESS, scanning.LESS_EQUAL)
+}
 
-	for r.match(scanning.GREATER, scanning.GREATER_EQUAL, scanning.LESS, scanning.LESS_EQUAL) {
-		operator := r.previous()
-		right, err := r.term()
-		return &ast2.Binary{
-			Left:     &expr,
-			Operator: operator,
-			Right:    &right,
-		}, err
-	}
-	return expr, err
+func (r *Parser) bitwiseOr() (ast2.Expr, *TokenError) {
+	return r.binary(r.bitwiseXor, scanning.PIPE)
+}
+
+func (r *Parser) bitwiseXor() (ast2.Expr, *TokenError) {
+	return r.binary(r.bitwiseAnd, scanning.CARET)
+}
+
+func (r *Parser) bitwiseAnd() (ast2.Expr, *TokenError) {
+	return r.binary(r.shift, scanning.AMPERSAND)
+}
+
+func (r *Parser) shift() (ast2.Expr, *TokenError) {
+	return r.binary(r.term, scanning.LESS_LESS, scanning.GREATER_GREATER)
 }
 
 func (r *Parser) term() (ast2.Expr, *TokenError) {
-	expr, err := r.factor()
+	return r.binary(r.factor, scanning.MINUS, scanning.PLUS)
+}
+
+func (r *Parser) factor() (ast2.Expr, *TokenError) {
+	return r.binary(r.unary, scanning.SLASH, scanning.STAR, scanning.TILDE_SLASH, scanning.PERCENT)
+}
+
+// binary parses left-associative binary operators of one precedence level,
+// operands are parsed by operand of the next higher level
+func (r *Parser) binary(operand func() (ast2.Expr, *TokenError), operators ...scanning.TokenType) (ast2.Expr, *TokenError) {
+	expr, err := operand()
+	if err != nil {
+		return nil, err
+	}
 
-	for r.match(scanning.MINUS, scanning.PLUS) {
+	for r.match(operators...) {
 		operator := r.previous()
-		right, err := r.term()
-		return &ast2.Binary{
-			Left:     &expr,
+		right, err := operand()
+		if err != nil {
+			return nil, err
+		}
+		left := expr
+		expr = &ast2.Binary{
+			Left:     &left,
 			Operator: operator,
 			Right:    &right,
-		}, err
+		}
 	}
-	return expr, err
+	return expr, nil
 }
 
-func (r *Parser) factor() (ast2.Expr, *TokenError) {
-	expr, err := r.unary()
-
-	for r.match(scanning.SLASH, scanning.STAR) {
+func (r *Parser) unary() (ast2.Expr, *TokenError) {
+	if r.match(scanning.PLUS_PLUS, scanning.MINUS_MINUS) {
 		operator := r.previous()
-		right, err := r.term()
-		return &ast2.Binary{
-			Left:     &expr,
+		target, err := r.unary()
+		if err != nil {
+			return nil, err
+		}
+		return increment(target, operator, false)
+	}
+	if r.match(scanning.SPAWN) {
+		keyword := r.previous()
+		expr, err := r.call()
+		if err != nil {
+			return nil, err
+		}
+		call, ok := expr.(*ast2.Call)
+		if !ok {
+			return nil, &TokenError{error: errors.New(expectedCallAfterSpawnMsg), Token: keyword}
+		}
+		return &ast2.Spawn{Keyword: keyword, Call: call}, nil
+	}
+	if r.match(scanning.BANG, scanning.MINUS, scanning.TILDE) {
+		operator := r.previous()
+		right, err := r.unary()
+		return &ast2.Unary{
 			Operator: operator,
 			Right:    &right,
 		}, err
 	}
-	return expr, err
+	return r.power()
 }
 
-func (r *Parser) unary() (ast2.Expr, *TokenError) {
-	for r.match(scanning.BANG, scanning.MINUS) {
+// power parses right-associative exponentiation, it binds tighter than unary
+// operator on its left, so -2 ** 2 is -4, but not on its right
+func (r *Parser) power() (ast2.Expr, *TokenError) {
+	expr, err := r.postfix()
+	if err != nil {
+		return nil, err
+	}
+	if r.match(scanning.STAR_STAR) {
 		operator := r.previous()
 		right, err := r.unary()
-		return &ast2.Unary{
+		if err != nil {
+			return nil, err
+		}
+		return &ast2.Binary{
+			Left:     &expr,
 			Operator: operator,
 			Right:    &right,
-		}, err
+		}, nil
+	}
+	return expr, nil
+}
+
+// postfix parses x++ and x--, which result in value before the change
+func (r *Parser) postfix() (ast2.Expr, *TokenError) {
+	expr, err := r.call()
+	if err != nil {
+		return nil, err
 	}
-	return r.call()
+	if r.match(scanning.PLUS_PLUS, scanning.MINUS_MINUS) {
+		return increment(expr, r.previous(), true)
+	}
+	return expr, nil
 }
 
 func (r *Parser) call() (ast2.Expr, *TokenError) {
@@ -276,14 +588,11 @@ func (r *Parser) call() (ast2.Expr, *TokenError) {
 		return nil, tokenError
 	}
 
-	for {
-		if r.match(scanning.LEFT_PAREN) {
-			expr, tokenError = r.finishCall(expr)
-			if tokenError != nil {
-				return nil, tokenError
-			}
+	for r.match(scanning.LEFT_PAREN) {
+		expr, tokenError = r.finishCall(expr)
+		if tokenError != nil {
+			return nil, tokenError
 		}
-		break
 	}
 
 	return expr, nil
@@ -292,19 +601,34 @@ func (r *Parser) call() (ast2.Expr, *TokenError) {
 
 func (r *Parser) finishCall(callee ast2.Expr) (ast2.Expr, *TokenError) {
 	args := make([]ast2.Expr, 0)
+	var named []*ast2.NamedArg
 
 	if !r.check(scanning.RIGHT_PAREN) {
-		arg, tokenError := r.expression()
-		if tokenError != nil {
-			return nil, tokenError
-		}
-		args = append(args, arg)
-		for r.match(scanning.COMMA) {
-			arg, tokenError := r.expression()
-			if tokenError != nil {
-				return nil, tokenError
+		for {
+			if r.check(scanning.IDENTIFIER) && r.checkNext(scanning.COLON) {
+				name := r.advance()
+				r.advance()
+				value, tokenError := r.expression()
+				if tokenError != nil {
+					return nil, tokenError
+				}
+				named = append(named, &ast2.NamedArg{Name: name, Value: value})
+			} else {
+				if len(named) > 0 {
+					return nil, &TokenError{
+						error: errors.New(positionalAfterNamedArgMsg),
+						Token: r.peek(),
+					}
+				}
+				arg, tokenError := r.expression()
+				if tokenError != nil {
+					return nil, tokenError
+				}
+				args = append(args, arg)
+			}
+			if !r.match(scanning.COMMA) {
+				break
 			}
-			args = append(args, arg)
 		}
 	}
 
@@ -317,6 +641,7 @@ func (r *Parser) finishCall(callee ast2.Expr) (ast2.Expr, *TokenError) {
 		Callee: callee,
 		Paren:  paren,
 		Params: args,
+		Named:  named,
 	}, nil
 }
 
@@ -342,15 +667,33 @@ func (r *Parser) primary() (ast2.Expr, *TokenError) {
 		}, nil
 	}
 
+	if r.match(scanning.INTERPOLATION) {
+		return r.interpolation()
+	}
+
+	if r.match(scanning.FUN) {
+		return r.lambda()
+	}
+
 	if r.match(scanning.LEFT_PAREN) {
+		lambda, tokenErr := r.arrowFunction()
+		if tokenErr != nil || lambda != nil {
+			return lambda, tokenErr
+		}
 		expr, tokenErr := r.expression()
+		if tokenErr != nil {
+			return nil, tokenErr
+		}
 		_, tokenErr = r.consume(scanning.RIGHT_PAREN, missingRightParenMsg)
 		if tokenErr != nil {
 			return nil, tokenErr
 		}
 		return &ast2.Grouping{Expression: &expr}, nil
 	}
-	return nil, nil
+	return nil, &TokenError{
+		error: errors.New(expectedExpressionMsg),
+		Token: r.peek(),
+	}
 }
 
 func (r *Parser) consume(t scanning.TokenType, message string) (*scanning.Token, *TokenError) {
@@ -394,6 +737,14 @@ func (r *Parser) check(t scanning.TokenType) bool {
 	}
 }
 
+// checkNext reports whether token after the current one is of type t
+func (r *Parser) checkNext(t scanning.TokenType) bool {
+	if r.isAtEnd() || r.current+1 >= len(r.tokens) {
+		return false
+	}
+	return r.tokens[r.current+1].TokenType == t
+}
+
 func (r *Parser) previous() *scanning.Token {
 	// TODO: add some check for out of bounds error
 	return &r.tokens[r.current-1]
@@ -438,10 +789,19 @@ func (r *Parser) synchronize() {
 
 func (r *Parser) returnStatement() (ast2.Stmt, *TokenError) {
 	keyword := r.previous()
+	if r.functionDepth == 0 {
+		return nil, &TokenError{
+			error: errors.New(returnOutsideFunctionMsg),
+			Token: keyword,
+		}
+	}
 
 	var err *TokenError
 	var value ast2.Expr
 	if !r.check(scanning.SEMICOLON) {
+		if r.generator {
+			return nil, &TokenError{error: errors.New(returnValueFromGeneratorMsg), Token: keyword}
+		}
 		value, err = r.expression()
 		if err != nil {
 			return nil, err
@@ -475,11 +835,12 @@ func (r *Parser) expressionStatement() (ast2.Stmt, *TokenError) {
 }
 
 func (r *Parser) assignment() (ast2.Expr, *TokenError) {
-	expr, tokenError := r.or()
+	expr, tokenError := r.conditional()
 	if tokenError != nil {
 		return nil, tokenError
 	}
-	if r.match(scanning.EQUAL) {
+	if r.match(scanning.EQUAL, scanning.PLUS_EQUAL, scanning.MINUS_EQUAL, scanning.STAR_EQUAL,
+		scanning.SLASH_EQUAL, scanning.PERCENT_EQUAL) {
 		eq := r.previous()
 		value, tokenError := r.assignment()
 		if tokenError != nil {
@@ -489,8 +850,9 @@ func (r *Parser) assignment() (ast2.Expr, *TokenError) {
 		if _, ok := expr.(*ast2.VarExpr); ok {
 			name := expr.(*ast2.VarExpr).Name
 			return &ast2.Assign{
-				Name:  name,
-				Value: value,
+				Name:     name,
+				Operator: compoundOperator(eq),
+				Value:    value,
 			}, nil
 		} else {
 			return nil, &TokenError{
@@ -502,6 +864,85 @@ func (r *Parser) assignment() (ast2.Expr, *TokenError) {
 	return expr, nil
 }
 
+// compoundOperator returns binary operator applied by compound assignment, nil for plain =
+func compoundOperator(assignment *scanning.Token) *scanning.Token {
+	tokenType, ok := compoundAssignments[assignment.TokenType]
+	if !ok {
+		return nil
+	}
+	operator := *assignment
+	operator.TokenType = tokenType
+	operator.Lexeme = assignment.Lexeme[:1]
+	return &operator
+}
+
+// increment turns ++ and -- into compound assignment of 1 to target
+func increment(target ast2.Expr, operator *scanning.Token, postfix bool) (ast2.Expr, *TokenError) {
+	variable, ok := target.(*ast2.VarExpr)
+	if !ok {
+		return nil, &TokenError{
+			error: invalidAssignmentTarget,
+			Token: operator,
+		}
+	}
+	return &ast2.Assign{
+		Name:     variable.Name,
+		Operator: compoundOperator(operator),
+		Value:    &ast2.Literal{Value: int64(1)},
+		Postfix:  postfix,
+	}, nil
+}
+
+// conditional parses right-associative condition ? then : else
+func (r *Parser) conditional() (ast2.Expr, *TokenError) {
+	condition, tokenError := r.coalesce()
+	if tokenError != nil {
+		return nil, tokenError
+	}
+	if !r.match(scanning.QUESTION) {
+		return condition, nil
+	}
+	question := r.previous()
+	then, tokenError := r.expression()
+	if tokenError != nil {
+		return nil, tokenError
+	}
+	if _, tokenError = r.consume(scanning.COLON, expectedColonInConditionalMsg); tokenError != nil {
+		return nil, tokenError
+	}
+	elseExpr, tokenError := r.conditional()
+	if tokenError != nil {
+		return nil, tokenError
+	}
+	return &ast2.Conditional{
+		Condition: condition,
+		Question:  question,
+		Then:      then,
+		Else:      elseExpr,
+	}, nil
+}
+
+// coalesce parses a ?? b, its right operand is evaluated only when the left one is nil
+func (r *Parser) coalesce() (ast2.Expr, *TokenError) {
+	expr, tokenError := r.or()
+	if tokenError != nil {
+		return nil, tokenError
+	}
+	for r.match(scanning.QUESTION_QUESTION) {
+		operator := r.previous()
+		right, tokenError := r.or()
+		if tokenError != nil {
+			return nil, tokenError
+		}
+		expr = &ast2.Logical{
+			Left:     expr,
+			Operator: operator,
+			Right:    right,
+		}
+	}
+	return expr, nil
+}
+
 func (r *Parser) or() (ast2.Expr, *TokenError) {
 	expr, tokenError := r.and()
 	if tokenError != nil {
@@ -552,11 +993,11 @@ func (r *Parser) block() (*ast2.Block, *TokenError) {
 		res = append(res, declaration)
 	}
 
-	_, err := r.consume(scanning.RIGHT_BRACE, expectedRightBraceMsg)
+	brace, err := r.consume(scanning.RIGHT_BRACE, expectedRightBraceMsg)
 	if err != nil {
 		return nil, err
 	}
-	return &ast2.Block{Statements: res}, nil
+	return &ast2.Block{Statements: res, Brace: brace}, nil
 }
 
 func (r *Parser) ifStatement() (ast2.Stmt, *TokenError) {
@@ -579,7 +1020,9 @@ func (r *Parser) ifStatement() (ast2.Stmt, *TokenError) {
 		return nil, err
 	}
 	if r.match(scanning.ELSE) {
-		elseStmt, err = r.statement()
+		if elseStmt, err = r.statement(); err != nil {
+			return nil, err
+		}
 	}
 
 	return &ast2.If{
@@ -589,18 +1032,246 @@ func (r *Parser) ifStatement() (ast2.Stmt, *TokenError) {
 	}, nil
 }
 
+func (r *Parser) selectStatement() (ast2.Stmt, *TokenError) {
+	keyword := r.previous()
+	if _, err := r.consume(scanning.LEFT_BRACE, missingLeftBraceAfterSelectMsg); err != nil {
+		return nil, err
+	}
+	cases := make([]*ast2.SelectCase, 0)
+	hasDefault := false
+	for !r.check(scanning.RIGHT_BRACE) && !r.isAtEnd() {
+		c, err := r.selectCase()
+		if err != nil {
+			return nil, err
+		}
+		if c.Channel == nil {
+			if hasDefault {
+				return nil, &TokenError{error: errors.New(duplicateDefaultCaseMsg), Token: c.Keyword}
+			}
+			hasDefault = true
+		}
+		cases = append(cases, c)
+	}
+	if len(cases) == 0 {
+		// select without cases would block forever
+		return nil, &TokenError{error: errors.New(emptySelectMsg), Token: keyword}
+	}
+	if _, err := r.consume(scanning.RIGHT_BRACE, expectedRightBraceMsg); err != nil {
+		return nil, err
+	}
+	return &ast2.Select{Keyword: keyword, Cases: cases}, nil
+}
+
+// selectCase parses case [name =] recv(channel) => statement, case send(channel, value) => statement
+// or case _ => statement
+func (r *Parser) selectCase() (*ast2.SelectCase, *TokenError) {
+	keyword, err := r.consume(scanning.CASE, expectedCaseMsg)
+	if err != nil {
+		return nil, err
+	}
+	c := &ast2.SelectCase{Keyword: keyword}
+	if r.check(scanning.IDENTIFIER) && r.peek().Lexeme == "_" && r.checkNext(scanning.ARROW) {
+		r.advance()
+	} else {
+		if r.check(scanning.IDENTIFIER) && r.checkNext(scanning.EQUAL) {
+			c.Binding = r.advance()
+			r.advance()
+		}
+		operation, err := r.consume(scanning.IDENTIFIER, expectedSelectOperationMsg)
+		if err != nil {
+			return nil, err
+		}
+		if operation.Lexeme != "recv" && (operation.Lexeme != "send" || c.Binding != nil) {
+			return nil, &TokenError{error: errors.New(expectedSelectOperationMsg), Token: operation}
+		}
+		if _, err = r.consume(scanning.LEFT_PAREN, fmt.Sprintf(expectedLeftParenInSelectMsg, operation.Lexeme)); err != nil {
+			return nil, err
+		}
+		if c.Channel, err = r.expression(); err != nil {
+			return nil, err
+		}
+		if operation.Lexeme == "send" {
+			if _, err = r.consume(scanning.COMMA, expectedCommaAfterChannelMsg); err != nil {
+				return nil, err
+			}
+			if c.Value, err = r.expression(); err != nil {
+				return nil, err
+			}
+		}
+		if _, err = r.consume(scanning.RIGHT_PAREN, fmt.Sprintf(expectedRightParenInSelectMsg, operation.Lexeme)); err != nil {
+			return nil, err
+		}
+	}
+	if _, err = r.consume(scanning.ARROW, expectedArrowInCaseMsg); err != nil {
+		return nil, err
+	}
+	if c.Body, err = r.statement(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func (r *Parser) yieldStatement() (ast2.Stmt, *TokenError) {
+	keyword := r.previous()
+	if !r.generator {
+		return nil, &TokenError{error: errors.New(yieldOutsideGeneratorMsg), Token: keyword}
+	}
+	var value ast2.Expr
+	if !r.check(scanning.SEMICOLON) {
+		var err *TokenError
+		if value, err = r.expression(); err != nil {
+			return nil, err
+		}
+	}
+	if _, err := r.consume(scanning.SEMICOLON, missingSemicolonAfterYieldMsg); err != nil {
+		return nil, err
+	}
+	return &ast2.Yield{Keyword: keyword, Value: value}, nil
+}
+
+func (r *Parser) matchStatement() (ast2.Stmt, *TokenError) {
+	keyword := r.previous()
+	if _, err := r.consume(scanning.LEFT_PAREN, missingLeftParenAfterMatchMsg); err != nil {
+		return nil, err
+	}
+	value, err := r.expression()
+	if err != nil {
+		return nil, err
+	}
+	if _, err = r.consume(scanning.RIGHT_PAREN, missingRightParenAfterMatchMsg); err != nil {
+		return nil, err
+	}
+	if _, err = r.consume(scanning.LEFT_BRACE, missingLeftBraceAfterMatchMsg); err != nil {
+		return nil, err
+	}
+
+	cases := make([]*ast2.Case, 0)
+	for !r.check(scanning.RIGHT_BRACE) && !r.isAtEnd() {
+		c, err := r.matchCase()
+		if err != nil {
+			return nil, err
+		}
+		cases = append(cases, c)
+	}
+	if _, err = r.consume(scanning.RIGHT_BRACE, expectedRightBraceMsg); err != nil {
+		return nil, err
+	}
+	return &ast2.Match{
+		Keyword: keyword,
+		Value:   value,
+		Cases:   cases,
+	}, nil
+}
+
+// matchCase parses case pattern, ... [if guard] => statement
+func (r *Parser) matchCase() (*ast2.Case, *TokenError) {
+	keyword, err := r.consume(scanning.CASE, expectedCaseMsg)
+	if err != nil {
+		return nil, err
+	}
+	patterns := make([]*ast2.Pattern, 0, 1)
+	for {
+		pattern, err := r.pattern()
+		if err != nil {
+			return nil, err
+		}
+		patterns = append(patterns, pattern)
+		if !r.match(scanning.COMMA) {
+			break
+		}
+	}
+	for _, pattern := range patterns {
+		if pattern.Binding != nil && pattern.Binding.Lexeme != "_" && len(patterns) > 1 {
+			return nil, &TokenError{error: errors.New(bindingInAlternativesMsg), Token: pattern.Binding}
+		}
+	}
+
+	var guard ast2.Expr
+	if r.match(scanning.IF) {
+		if guard, err = r.expression(); err != nil {
+			return nil, err
+		}
+	}
+	if _, err = r.consume(scanning.ARROW, expectedArrowInCaseMsg); err != nil {
+		return nil, err
+	}
+	body, err := r.statement()
+	if err != nil {
+		return nil, err
+	}
+	return &ast2.Case{
+		Keyword:  keyword,
+		Patterns: patterns,
+		Guard:    guard,
+		Body:     body,
+	}, nil
+}
+
+// pattern parses _, binding name, value or range of values from..to and from..=to
+func (r *Parser) pattern() (*ast2.Pattern, *TokenError) {
+	if r.match(scanning.IDENTIFIER) {
+		return &ast2.Pattern{Binding: r.previous()}, nil
+	}
+	value, err := r.patternValue()
+	if err != nil {
+		return nil, err
+	}
+	if !r.match(scanning.DOT_DOT, scanning.DOT_DOT_EQUAL) {
+		return &ast2.Pattern{Value: value}, nil
+	}
+	inclusive := r.previous().TokenType == scanning.DOT_DOT_EQUAL
+	to, err := r.patternValue()
+	if err != nil {
+		return nil, err
+	}
+	return &ast2.Pattern{Value: value, To: to, Inclusive: inclusive}, nil
+}
+
+// patternValue parses literal, negated number, variable or expression in parentheses.
+// Unlike primary it never tries arrow function, which (x) => ... would look like.
+func (r *Parser) patternValue() (ast2.Expr, *TokenError) {
+	if r.match(scanning.MINUS) {
+		operator := r.previous()
+		if !r.check(scanning.NUMBER) {
+			return nil, &TokenError{error: errors.New(expectedPatternMsg), Token: r.peek()}
+		}
+		var right ast2.Expr = &ast2.Literal{Value: r.advance().Literal}
+		return &ast2.Unary{Operator: operator, Right: &right}, nil
+	}
+	if r.match(scanning.LEFT_PAREN) {
+		expr, err := r.expression()
+		if err != nil {
+			return nil, err
+		}
+		if _, err = r.consume(scanning.RIGHT_PAREN, missingRightParenMsg); err != nil {
+			return nil, err
+		}
+		return &ast2.Grouping{Expression: &expr}, nil
+	}
+	for _, t := range []scanning.TokenType{scanning.NUMBER, scanning.STRING, scanning.INTERPOLATION,
+		scanning.TRUE, scanning.FALSE, scanning.NIL, scanning.IDENTIFIER} {
+		if r.check(t) {
+			return r.primary()
+		}
+	}
+	return nil, &TokenError{error: errors.New(expectedPatternMsg), Token: r.peek()}
+}
+
 func (r *Parser) whileStatement() (ast2.Stmt, *TokenError) {
 	_, err := r.consume(scanning.LEFT_PAREN, missingLeftParenAfterWhileMsg)
 	if err != nil {
 		return nil, err
 	}
 	condition, err := r.expression()
+	if err != nil {
+		return nil, err
+	}
 	_, err = r.consume(scanning.RIGHT_PAREN, missingRightParenAfterWhileMsg)
 	if err != nil {
 		return nil, err
 	}
 
-	whileBody, err := r.statement()
+	whileBody, err := r.loopBody()
 	if err != nil {
 		return nil, err
 	}
@@ -616,6 +1287,9 @@ func (r *Parser) forStatement() (ast2.Stmt, *TokenError) {
 	if err != nil {
 		return nil, err
 	}
+	if r.check(scanning.IDENTIFIER) && r.checkNext(scanning.IN) {
+		return r.forInStatement()
+	}
 
 	var initializer ast2.Stmt
 	var condition ast2.Expr
@@ -659,23 +1333,18 @@ func (r *Parser) forStatement() (ast2.Stmt, *TokenError) {
 		return nil, err
 	}
 
-	body, err := r.statement()
+	body, err := r.loopBody()
 	if err != nil {
 		return nil, err
 	}
 
-	if increment != nil {
-		incrementExprStmt := ast2.Expression{Expression: &increment}
-		statements := []ast2.Stmt{body, &incrementExprStmt}
-		body = &ast2.Block{Statements: statements}
-	}
-
 	if condition == nil {
 		condition = &ast2.Literal{Value: true}
 	}
 	body = &ast2.While{
 		Condition: condition,
 		Statement: body,
+		Increment: increment,
 	}
 
 	if initializer != nil {
@@ -686,3 +1355,62 @@ func (r *Parser) forStatement() (ast2.Stmt, *TokenError) {
 	return body, err
 
 }
+
+// forInStatement parses rest of for (name in iterable) statement
+func (r *Parser) forInStatement() (ast2.Stmt, *TokenError) {
+	name := r.advance()
+	in := r.advance()
+	iterable, err := r.expression()
+	if err != nil {
+		return nil, err
+	}
+	if _, err = r.consume(scanning.RIGHT_PAREN, missingRightParenAfterForMsg); err != nil {
+		return nil, err
+	}
+	body, err := r.loopBody()
+	if err != nil {
+		return nil, err
+	}
+	return &ast2.ForIn{
+		Name:     name,
+		In:       in,
+		Iterable: iterable,
+		Body:     body,
+	}, nil
+}
+
+func (r *Parser) loopBody() (ast2.Stmt, *TokenError) {
+	r.loopDepth++
+	defer func() {
+		r.loopDepth--
+	}()
+	return r.statement()
+}
+
+// loopJumpStatement parses break or continue statement, keyword is already consumed
+func (r *Parser) loopJumpStatement() (ast2.Stmt, *TokenError) {
+	keyword := r.previous()
+	isBreak := keyword.TokenType == scanning.BREAK
+	if r.loopDepth == 0 {
+		msg := continueOutsideLoopMsg
+		if isBreak {
+			msg = breakOutsideLoopMsg
+		}
+		return nil, &TokenError{
+			error: errors.New(msg),
+			Token: keyword,
+		}
+	}
+
+	msg := missingSemicolonAfterContinueMsg
+	if isBreak {
+		msg = missingSemicolonAfterBreakMsg
+	}
+	if _, err := r.consume(scanning.SEMICOLON, msg); err != nil {
+		return nil, err
+	}
+	if isBreak {
+		return &ast2.Break{Keyword: keyword}, nil
+	}
+	return &ast2.Continue{Keyword: keyword}, nil
+}
@@ -0,0 +1,437 @@
+package runtime
+
+import (
+	"errors"
+	"gox/internal/decimal"
+	"gox/internal/scanning"
+	"math"
+	"math/big"
+)
+
+// Numbers are int64, produced by integer literals, *big.Int, produced by literals
+// with suffix n, decimal.Decimal, produced by literals with suffix d, or float64.
+// Operation on two numbers of different kind converts one of them to the more
+// general kind in order int64, *big.Int, decimal.Decimal. Mixing any of them with
+// float results in float, except for decimals which can't be mixed with floats
+// as that would lose their exactness. Division of integers results in float.
+
+var (
+	integerOverflow    = errors.New("integer overflow")
+	divisionByZero     = errors.New("division by zero")
+	decimalFloatMixed  = errors.New("can't mix decimal and float numbers")
+	negativeShift      = errors.New("negative shift count")
+	exponentTooLarge   = errors.New("exponent too large")
+	nonIntegerExponent = errors.New("exponent of decimal must be integer")
+)
+
+// maxBigExponent limits exponents and shifts of big integers, which would
+// otherwise exhaust memory
+const maxBigExponent = 1 << 24
+
+type numberKind int
+
+const (
+	intKind numberKind = iota
+	bigKind
+	decimalKind
+	floatKind
+)
+
+func kind(number any) numberKind {
+	switch number.(type) {
+	case *big.Int:
+		return bigKind
+	case decimal.Decimal:
+		return decimalKind
+	case float64:
+		return floatKind
+	}
+	return intKind
+}
+
+// commonKind returns kind both operands are converted to
+func commonKind(left, right any) (numberKind, error) {
+	a, b := kind(left), kind(right)
+	if a == decimalKind && b == floatKind || a == floatKind && b == decimalKind {
+		return 0, decimalFloatMixed
+	}
+	if a > b {
+		return a, nil
+	}
+	return b, nil
+}
+
+func isNumber(value any) bool {
+	switch value.(type) {
+	case int64, *big.Int, decimal.Decimal, float64:
+		return true
+	}
+	return false
+}
+
+func toFloat(number any) float64 {
+	switch n := number.(type) {
+	case int64:
+		return float64(n)
+	case *big.Int:
+		f, _ := new(big.Float).SetInt(n).Float64()
+		return f
+	}
+	return number.(float64)
+}
+
+func toBig(number any) *big.Int {
+	if i, ok := number.(int64); ok {
+		return big.NewInt(i)
+	}
+	return number.(*big.Int)
+}
+
+func toDecimal(number any) decimal.Decimal {
+	if d, ok := number.(decimal.Decimal); ok {
+		return d
+	}
+	return decimal.FromInt(toBig(number))
+}
+
+// toInteger converts integer or float without fractional part to int64
+func toInteger(value any) (int64, bool) {
+	switch number := value.(type) {
+	case int64:
+		return number, true
+	case *big.Int:
+		return number.Int64(), number.IsInt64()
+	case float64:
+		if number == math.Trunc(number) && math.Abs(number) < 1<<63 {
+			return int64(number), true
+		}
+	}
+	return 0, false
+}
+
+// arithmetic applies arithmetic operator to two numbers, context is used for
+// division of decimals
+func arithmetic(operator scanning.TokenType, left, right any, context decimal.Context) (any, error) {
+	common, err := commonKind(left, right)
+	if err != nil {
+		return nil, err
+	}
+	if operator == scanning.SLASH && (common == intKind || common == bigKind) {
+		common = floatKind
+	}
+
+	switch common {
+	case intKind:
+		return integerArithmetic(operator, left.(int64), right.(int64))
+	case bigKind:
+		return bigArithmetic(operator, toBig(left), toBig(right))
+	case decimalKind:
+		return decimalArithmetic(operator, toDecimal(left), toDecimal(right), context)
+	}
+
+	x, y := toFloat(left), toFloat(right)
+	switch operator {
+	case scanning.PLUS:
+		return x + y, nil
+	case scanning.MINUS:
+		return x - y, nil
+	case scanning.STAR:
+		return x * y, nil
+	case scanning.SLASH:
+		return x / y, nil
+	case scanning.TILDE_SLASH:
+		return math.Floor(x / y), nil
+	case scanning.PERCENT:
+		// sign of result follows divisor, so that x == (x ~/ y) * y + x % y
+		m := math.Mod(x, y)
+		if m != 0 && (m < 0) != (y < 0) {
+			m += y
+		}
+		return m, nil
+	case scanning.STAR_STAR:
+		return math.Pow(x, y), nil
+	}
+	return nil, errors.New("unknown arithmetic operator")
+}
+
+func integerArithmetic(operator scanning.TokenType, a, b int64) (any, error) {
+	switch operator {
+	case scanning.PLUS:
+		if b > 0 && a > math.MaxInt64-b || b < 0 && a < math.MinInt64-b {
+			return nil, integerOverflow
+		}
+		return a + b, nil
+	case scanning.MINUS:
+		if b < 0 && a > math.MaxInt64+b || b > 0 && a < math.MinInt64+b {
+			return nil, integerOverflow
+		}
+		return a - b, nil
+	case scanning.STAR:
+		res := a * b
+		if a != 0 && (res/a != b || a == -1 && b == math.MinInt64) {
+			return nil, integerOverflow
+		}
+		return res, nil
+	case scanning.TILDE_SLASH:
+		if b == 0 {
+			return nil, divisionByZero
+		}
+		if a == math.MinInt64 && b == -1 {
+			return nil, integerOverflow
+		}
+		// round towards negative infinity like floats do
+		q := a / b
+		if a%b != 0 && (a < 0) != (b < 0) {
+			q--
+		}
+		return q, nil
+	case scanning.PERCENT:
+		if b == 0 {
+			return nil, divisionByZero
+		}
+		m := a % b
+		if m != 0 && (m < 0) != (b < 0) {
+			m += b
+		}
+		return m, nil
+	case scanning.STAR_STAR:
+		if b < 0 {
+			return math.Pow(float64(a), float64(b)), nil
+		}
+		return integerPower(a, b)
+	}
+	return nil, errors.New("unknown arithmetic operator")
+}
+
+// integerPower computes base ** exponent by repeated squaring, exponent is not negative
+func integerPower(base, exponent int64) (any, error) {
+	res := int64(1)
+	for exponent > 0 {
+		if exponent&1 == 1 {
+			product, err := integerArithmetic(scanning.STAR, res, base)
+			if err != nil {
+				return nil, err
+			}
+			res = product.(int64)
+		}
+		exponent >>= 1
+		if exponent > 0 {
+			square, err := integerArithmetic(scanning.STAR, base, base)
+			if err != nil {
+				return nil, err
+			}
+			base = square.(int64)
+		}
+	}
+	return res, nil
+}
+
+func bigArithmetic(operator scanning.TokenType, a, b *big.Int) (any, error) {
+	switch operator {
+	case scanning.PLUS:
+		return new(big.Int).Add(a, b), nil
+	case scanning.MINUS:
+		return new(big.Int).Sub(a, b), nil
+	case scanning.STAR:
+		return new(big.Int).Mul(a, b), nil
+	case scanning.TILDE_SLASH:
+		if b.Sign() == 0 {
+			return nil, divisionByZero
+		}
+		q, m := new(big.Int).QuoRem(a, b, new(big.Int))
+		if m.Sign() != 0 && a.Sign() != b.Sign() {
+			q.Sub(q, big.NewInt(1))
+		}
+		return q, nil
+	case scanning.PERCENT:
+		if b.Sign() == 0 {
+			return nil, divisionByZero
+		}
+		m := new(big.Int).Rem(a, b)
+		if m.Sign() != 0 && m.Sign() != b.Sign() {
+			m.Add(m, b)
+		}
+		return m, nil
+	case scanning.STAR_STAR:
+		if b.Sign() < 0 {
+			return math.Pow(toFloat(a), toFloat(b)), nil
+		}
+		if b.Cmp(big.NewInt(maxBigExponent)) > 0 {
+			return nil, exponentTooLarge
+		}
+		return new(big.Int).Exp(a, b, nil), nil
+	}
+	return nil, errors.New("unknown arithmetic operator")
+}
+
+func decimalArithmetic(operator scanning.TokenType, a, b decimal.Decimal, context decimal.Context) (any, error) {
+	switch operator {
+	case scanning.PLUS:
+		return a.Add(b), nil
+	case scanning.MINUS:
+		return a.Sub(b), nil
+	case scanning.STAR:
+		return a.Mul(b), nil
+	case scanning.SLASH:
+		return a.Quo(b, context)
+	case scanning.TILDE_SLASH:
+		return a.Quo(b, decimal.Context{Scale: 0, Rounding: decimal.Floor})
+	case scanning.PERCENT:
+		q, err := a.Quo(b, decimal.Context{Scale: 0, Rounding: decimal.Floor})
+		if err != nil {
+			return nil, err
+		}
+		return a.Sub(q.Mul(b)), nil
+	case scanning.STAR_STAR:
+		exponent, ok := b.Integer()
+		if !ok {
+			return nil, nonIntegerExponent
+		}
+		if new(big.Int).Abs(exponent).Cmp(big.NewInt(maxBigExponent)) > 0 {
+			return nil, exponentTooLarge
+		}
+		return a.Pow(exponent.Int64(), context)
+	}
+	return nil, errors.New("unknown arithmetic operator")
+}
+
+func isInteger(value any) bool {
+	switch value.(type) {
+	case int64, *big.Int:
+		return true
+	}
+	return false
+}
+
+// bitwise applies bitwise or shift operator to two integers
+func bitwise(operator scanning.TokenType, left, right any) (any, error) {
+	a, leftIsInt := left.(int64)
+	b, rightIsInt := right.(int64)
+	if leftIsInt && rightIsInt {
+		switch operator {
+		case scanning.AMPERSAND:
+			return a & b, nil
+		case scanning.PIPE:
+			return a | b, nil
+		case scanning.CARET:
+			return a ^ b, nil
+		case scanning.LESS_LESS:
+			if b < 0 {
+				return nil, negativeShift
+			}
+			if a == 0 {
+				return a, nil
+			}
+			if b >= 64 || a<<b>>b != a {
+				return nil, integerOverflow
+			}
+			return a << b, nil
+		case scanning.GREATER_GREATER:
+			if b < 0 {
+				return nil, negativeShift
+			}
+			if b >= 64 {
+				b = 63
+			}
+			return a >> b, nil
+		}
+		return nil, errors.New("unknown bitwise operator")
+	}
+
+	x, y := toBig(left), toBig(right)
+	switch operator {
+	case scanning.AMPERSAND:
+		return new(big.Int).And(x, y), nil
+	case scanning.PIPE:
+		return new(big.Int).Or(x, y), nil
+	case scanning.CARET:
+		return new(big.Int).Xor(x, y), nil
+	}
+	if y.Sign() < 0 {
+		return nil, negativeShift
+	}
+	if y.Cmp(big.NewInt(maxBigExponent)) > 0 {
+		return nil, exponentTooLarge
+	}
+	if operator == scanning.LESS_LESS {
+		return new(big.Int).Lsh(x, uint(y.Uint64())), nil
+	}
+	return new(big.Int).Rsh(x, uint(y.Uint64())), nil
+}
+
+// complement returns bitwise negation of integer
+func complement(integer any) any {
+	if i, ok := integer.(int64); ok {
+		return ^i
+	}
+	return new(big.Int).Not(integer.(*big.Int))
+}
+
+// negate returns number with opposite sign
+func negate(number any) (any, error) {
+	switch n := number.(type) {
+	case int64:
+		if n == math.MinInt64 {
+			return nil, integerOverflow
+		}
+		return -n, nil
+	case *big.Int:
+		return new(big.Int).Neg(n), nil
+	case decimal.Decimal:
+		return n.Neg(), nil
+	}
+	return -number.(float64), nil
+}
+
+// compareNumbers applies comparison operator, including == and !=, to two numbers
+func compareNumbers(operator scanning.TokenType, left, right any) (bool, error) {
+	common, err := commonKind(left, right)
+	if err != nil {
+		return false, err
+	}
+
+	var cmp int
+	switch common {
+	case intKind:
+		a, b := left.(int64), right.(int64)
+		if a < b {
+			cmp = -1
+		} else if a > b {
+			cmp = 1
+		}
+	case bigKind:
+		cmp = toBig(left).Cmp(toBig(right))
+	case decimalKind:
+		cmp = toDecimal(left).Cmp(toDecimal(right))
+	case floatKind:
+		// compared directly, as NaN is neither less, equal nor greater
+		x, y := toFloat(left), toFloat(right)
+		switch operator {
+		case scanning.EQUAL_EQUAL:
+			return x == y, nil
+		case scanning.BANG_EQUAL:
+			return x != y, nil
+		case scanning.GREATER:
+			return x > y, nil
+		case scanning.GREATER_EQUAL:
+			return x >= y, nil
+		case scanning.LESS:
+			return x < y, nil
+		}
+		return x <= y, nil
+	}
+
+	switch operator {
+	case scanning.EQUAL_EQUAL:
+		return cmp == 0, nil
+	case scanning.BANG_EQUAL:
+		return cmp != 0, nil
+	case scanning.GREATER:
+		return cmp > 0, nil
+	case scanning.GREATER_EQUAL:
+		return cmp >= 0, nil
+	case scanning.LESS:
+		return cmp < 0, nil
+	}
+	return cmp <= 0, nil
+}
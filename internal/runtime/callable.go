@@ -5,11 +5,25 @@ import (
 	"gox/internal/ast"
 )
 
+// Variadic is maximal arity of callables accepting any number of arguments
+const Variadic = -1
+
 type Callable interface {
-	Arity() int
+	// Arity returns minimal and maximal number of arguments accepted
+	Arity() (int, int)
 	Call(interpreter *Interpreter, args []any) (any, *internal.RuntimeError)
 }
 
+// NamedParams is implemented by callables accepting arguments by parameter name
+type NamedParams interface {
+	Callable
+	// ParamNames returns names of parameters in order of their positions
+	ParamNames() []string
+}
+
+// missingArgument takes position of optional parameter omitted by call with named arguments
+var missingArgument = &struct{}{}
+
 // LoxStdFunction represents native function implemented by interpreter
 type LoxStdFunction interface {
 	Callable
@@ -19,20 +33,97 @@ type LoxStdFunction interface {
 // LoxFunction represents user-defined function/method
 type LoxFunction struct {
 	declaration ast.Function
+	closure     *environment
+}
+
+// tailCall is the result of function returning call of another user-defined
+// function. Instead of nesting the call, the caller makes it once the returning
+// function's frame is gone, so tail recursion runs in constant stack space.
+type tailCall struct {
+	function *LoxFunction
+	args     []any
+}
+
+// String returns "<fn name>", anonymous functions have no name
+func (r *LoxFunction) String() string {
+	if r.declaration.Name == nil {
+		return "<fn>"
+	}
+	return "<fn " + r.declaration.Name.Lexeme + ">"
 }
 
-func (r *LoxFunction) Arity() int {
-	return len(r.declaration.Params)
+func (r *LoxFunction) Arity() (int, int) {
+	minArgs := 0
+	for _, param := range r.declaration.Params {
+		if param.Rest {
+			return minArgs, Variadic
+		}
+		if param.Default == nil {
+			minArgs++
+		}
+	}
+	return minArgs, len(r.declaration.Params)
+}
+
+// ParamNames returns names of parameters except the rest one, which can't be passed by name
+func (r *LoxFunction) ParamNames() []string {
+	names := make([]string, 0, len(r.declaration.Params))
+	for _, param := range r.declaration.Params {
+		if !param.Rest {
+			names = append(names, param.Name.Lexeme)
+		}
+	}
+	return names
+}
+
+func (r *LoxFunction) Call(interpreter *Interpreter, args []any) (any, *internal.RuntimeError) {
+	if r.declaration.Generator {
+		return newGenerator(r, args), nil
+	}
+	function := r
+	for {
+		res, err := function.invoke(interpreter, args)
+		if err != nil {
+			return nil, err
+		}
+		next, ok := res.(*tailCall)
+		if !ok {
+			return res, nil
+		}
+		function, args = next.function, next.args
+	}
 }
 
-func (r *LoxFunction) Call(interpreter *Interpreter, args []any) (res any, err *internal.RuntimeError) {
-	defer func() {
-		res = recover()
-	}()
-	env := newEnvironment(interpreter.Env)
+// invoke executes body of the function once, result may be a tailCall to be made by the caller
+func (r *LoxFunction) invoke(interpreter *Interpreter, args []any) (any, *internal.RuntimeError) {
+	env := newEnvironment(r.closure)
 	for i, param := range r.declaration.Params {
-		env.define(param.Lexeme, args[i])
+		switch {
+		case param.Rest:
+			rest := make([]any, 0)
+			if i < len(args) {
+				rest = append(rest, args[i:]...)
+			}
+			env.define(param.Name.Lexeme, NewList(rest))
+		case i < len(args) && args[i] != missingArgument:
+			env.define(param.Name.Lexeme, args[i])
+		default:
+			// default values are evaluated on each call and see preceding parameters
+			value, err := interpreter.evaluateIn(param.Default, env)
+			if err != nil {
+				return nil, err
+			}
+			env.define(param.Name.Lexeme, value)
+		}
 	}
 
-	return nil, interpreter.executeBlock(r.declaration.Body, env)
+	completion, err := interpreter.executeBlock(r.declaration.Body, env)
+	if err != nil {
+		return nil, err
+	}
+	if completion.Flow != internal.Return {
+		// function without return statement returns nil
+		return nil, nil
+	}
+	return completion.Value, nil
 }
@@ -0,0 +1,394 @@
+package runtime
+
+import (
+	"errors"
+	"fmt"
+	"gox/internal"
+	"math/rand"
+	"sync"
+)
+
+var (
+	closedChannel        = errors.New("send on closed channel")
+	channelAlreadyClosed = errors.New("channel is already closed")
+	deadlock             = errors.New("deadlock: all tasks are blocked")
+)
+
+// scheduler keeps track of tasks of a program, so that the program can wait for them
+// before it ends and tasks blocked forever are reported instead of crashing the process.
+// Its mutex guards all channels, tasks and generators of the program.
+type scheduler struct {
+	mutex sync.Mutex
+	// running is number of tasks which aren't blocked, the main program included
+	running int
+	blocked []*waiter
+	// tasks which are still running or failed without anybody waiting for them
+	tasks []*Task
+}
+
+func newScheduler() *scheduler {
+	return &scheduler{running: 1}
+}
+
+// waiter is a blocked task, which is woken once one of operations it waits for proceeds
+type waiter struct {
+	wake  chan struct{}
+	woken bool
+	// index identifies select case which proceeded
+	index int
+	value any
+	ok    bool
+	err   error
+}
+
+func newWaiter() *waiter {
+	return &waiter{wake: make(chan struct{})}
+}
+
+// block suspends the calling task until w is woken, mutex must be held.
+// Once all tasks are blocked, none of them can be woken by another one, so all
+// are woken with deadlock error.
+func (r *scheduler) block(w *waiter) {
+	r.blocked = append(r.blocked, w)
+	r.running--
+	r.checkDeadlock()
+	r.mutex.Unlock()
+	<-w.wake
+	r.mutex.Lock()
+}
+
+func (r *scheduler) checkDeadlock() {
+	if r.running > 0 {
+		return
+	}
+	for len(r.blocked) > 0 {
+		r.wake(r.blocked[0], -1, nil, false, deadlock)
+	}
+}
+
+// wake resumes blocked task with result of its operation, false is returned if it
+// was already woken by another one
+func (r *scheduler) wake(w *waiter, index int, value any, ok bool, err error) bool {
+	if w.woken {
+		return false
+	}
+	w.woken = true
+	w.index, w.value, w.ok, w.err = index, value, ok, err
+	for i, blocked := range r.blocked {
+		if blocked == w {
+			r.blocked = append(r.blocked[:i], r.blocked[i+1:]...)
+			break
+		}
+	}
+	r.running++
+	close(w.wake)
+	return true
+}
+
+// join waits until all spawned tasks end and returns error of the first one which
+// failed without anybody waiting for its result
+func (r *scheduler) join() *internal.RuntimeError {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	for {
+		var running *Task
+		for _, task := range r.tasks {
+			if !task.done {
+				running = task
+				break
+			}
+		}
+		if running == nil {
+			break
+		}
+		// tasks woken by deadlock fail, so deadlock error is not reported here
+		w := newWaiter()
+		running.waiters = append(running.waiters, w)
+		r.block(w)
+	}
+	var err *internal.RuntimeError
+	if len(r.tasks) > 0 {
+		err = r.tasks[0].err
+	}
+	r.tasks = nil
+	return err
+}
+
+// forget stops tracking task whose result doesn't need to be reported
+func (r *scheduler) forget(task *Task) {
+	for i, t := range r.tasks {
+		if t == task {
+			r.tasks = append(r.tasks[:i], r.tasks[i+1:]...)
+			return
+		}
+	}
+}
+
+// Task is a function call started by spawn, running on a goroutine of its own
+type Task struct {
+	scheduler *scheduler
+	done      bool
+	result    any
+	err       *internal.RuntimeError
+	waiters   []*waiter
+}
+
+// spawn calls function on a new goroutine with its own copy of interpreter,
+// so that the current environment of the caller isn't shared
+func spawn(interpreter *Interpreter, function Callable, args []any) *Task {
+	s := interpreter.scheduler
+	task := &Task{scheduler: s}
+	s.mutex.Lock()
+	s.running++
+	s.tasks = append(s.tasks, task)
+	s.mutex.Unlock()
+
+	copied := *interpreter
+	copied.generator = nil
+	go func() {
+		var result any
+		var err *internal.RuntimeError
+		defer func() {
+			if p := recover(); p != nil {
+				err = &internal.RuntimeError{
+					Error: fmt.Errorf("%w: %v", internal.InternalError, p),
+				}
+			}
+			task.finish(result, err)
+		}()
+		result, err = function.Call(&copied, args)
+	}()
+	return task
+}
+
+// finish stores result of the task and wakes tasks waiting for it
+func (r *Task) finish(result any, err *internal.RuntimeError) {
+	s := r.scheduler
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	r.done, r.result, r.err = true, result, err
+	for _, w := range r.waiters {
+		s.wake(w, 0, nil, false, nil)
+	}
+	r.waiters = nil
+	if err == nil {
+		s.forget(r)
+	}
+	s.running--
+	s.checkDeadlock()
+}
+
+// wait blocks until the task ends and returns its result, or the error it failed with
+func (r *Task) wait() (any, *internal.RuntimeError) {
+	s := r.scheduler
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if !r.done {
+		w := newWaiter()
+		r.waiters = append(r.waiters, w)
+		s.block(w)
+		if w.err != nil {
+			return nil, &internal.RuntimeError{Error: w.err}
+		}
+	}
+	// error is reported to the caller, so it isn't reported once the program ends
+	s.forget(r)
+	return r.result, r.err
+}
+
+func (r *Task) String() string {
+	return "<task>"
+}
+
+// Channel passes values between tasks, sending blocks until the value is received
+// unless the channel was created with capacity and its buffer is not full
+type Channel struct {
+	scheduler *scheduler
+	buffer    []any
+	capacity  int
+	closed    bool
+	senders   []pending
+	receivers []pending
+}
+
+// pending is operation of blocked task waiting for the channel
+type pending struct {
+	waiter *waiter
+	// index identifies select case of the operation
+	index int
+	value any
+}
+
+func newChannel(scheduler *scheduler, capacity int) *Channel {
+	return &Channel{scheduler: scheduler, capacity: capacity}
+}
+
+func (r *Channel) send(value any) error {
+	s := r.scheduler
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if done, err := r.trySend(value); done {
+		return err
+	}
+	w := newWaiter()
+	r.senders = append(r.senders, pending{waiter: w, value: value})
+	s.block(w)
+	r.remove(w)
+	return w.err
+}
+
+// recv returns next value sent to the channel, false once it's closed and drained
+func (r *Channel) recv() (any, bool, error) {
+	s := r.scheduler
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if value, ok, done := r.tryRecv(); done {
+		return value, ok, nil
+	}
+	w := newWaiter()
+	r.receivers = append(r.receivers, pending{waiter: w})
+	s.block(w)
+	r.remove(w)
+	return w.value, w.ok, w.err
+}
+
+// trySend passes value to a blocked receiver or stores it in the buffer,
+// false is returned if neither is possible and the sender has to block
+func (r *Channel) trySend(value any) (bool, error) {
+	if r.closed {
+		return true, closedChannel
+	}
+	for len(r.receivers) > 0 {
+		receiver := r.receivers[0]
+		r.receivers = r.receivers[1:]
+		if r.scheduler.wake(receiver.waiter, receiver.index, value, true, nil) {
+			return true, nil
+		}
+	}
+	if len(r.buffer) < r.capacity {
+		r.buffer = append(r.buffer, value)
+		return true, nil
+	}
+	return false, nil
+}
+
+// tryRecv takes value from the buffer or a blocked sender, false is returned
+// if there is none and the receiver has to block
+func (r *Channel) tryRecv() (value any, ok bool, done bool) {
+	if len(r.buffer) > 0 {
+		value = r.buffer[0]
+		r.buffer = r.buffer[1:]
+		// blocked sender can put its value into the freed room
+		if sent, found := r.takeSent(); found {
+			r.buffer = append(r.buffer, sent)
+		}
+		return value, true, true
+	}
+	if sent, found := r.takeSent(); found {
+		return sent, true, true
+	}
+	return nil, false, r.closed
+}
+
+// takeSent returns value of the first blocked sender, which is woken
+func (r *Channel) takeSent() (any, bool) {
+	for len(r.senders) > 0 {
+		sender := r.senders[0]
+		r.senders = r.senders[1:]
+		if r.scheduler.wake(sender.waiter, sender.index, nil, false, nil) {
+			return sender.value, true
+		}
+	}
+	return nil, false
+}
+
+// remove drops operations of w, which was woken by another channel or by deadlock
+func (r *Channel) remove(w *waiter) {
+	r.senders = removePending(r.senders, w)
+	r.receivers = removePending(r.receivers, w)
+}
+
+func removePending(operations []pending, w *waiter) []pending {
+	res := operations[:0]
+	for _, operation := range operations {
+		if operation.waiter != w {
+			res = append(res, operation)
+		}
+	}
+	return res
+}
+
+func (r *Channel) close() error {
+	s := r.scheduler
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if r.closed {
+		return channelAlreadyClosed
+	}
+	r.closed = true
+	// receivers wait only while the buffer is empty, so they get no value
+	for _, receiver := range r.receivers {
+		s.wake(receiver.waiter, receiver.index, nil, false, nil)
+	}
+	for _, sender := range r.senders {
+		s.wake(sender.waiter, sender.index, nil, false, closedChannel)
+	}
+	r.receivers, r.senders = nil, nil
+	return nil
+}
+
+// Next makes channel an Iterator of values received until it's closed
+func (r *Channel) Next(*Interpreter) (any, bool, *internal.RuntimeError) {
+	value, ok, err := r.recv()
+	if err != nil {
+		return nil, false, &internal.RuntimeError{Error: err}
+	}
+	return value, ok, nil
+}
+
+func (r *Channel) String() string {
+	return "<channel>"
+}
+
+// channelOperation is a case of select statement, value is sent unless receive is set
+type channelOperation struct {
+	channel *Channel
+	receive bool
+	value   any
+}
+
+// selectOperation performs one of operations which can proceed, chosen at random, and
+// returns its index and received value. If none can, it blocks until one can, unless
+// wait is false, in which case -1 is returned right away.
+func (r *scheduler) selectOperation(operations []channelOperation, wait bool) (int, any, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	for _, i := range rand.Perm(len(operations)) {
+		operation := operations[i]
+		if operation.receive {
+			if value, _, done := operation.channel.tryRecv(); done {
+				return i, value, nil
+			}
+		} else if done, err := operation.channel.trySend(operation.value); done {
+			return i, nil, err
+		}
+	}
+	if !wait {
+		return -1, nil, nil
+	}
+
+	w := newWaiter()
+	for i, operation := range operations {
+		p := pending{waiter: w, index: i, value: operation.value}
+		if operation.receive {
+			operation.channel.receivers = append(operation.channel.receivers, p)
+		} else {
+			operation.channel.senders = append(operation.channel.senders, p)
+		}
+	}
+	r.block(w)
+	for _, operation := range operations {
+		operation.channel.remove(w)
+	}
+	return w.index, w.value, w.err
+}
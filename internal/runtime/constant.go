@@ -0,0 +1,42 @@
+package runtime
+
+import (
+	"gox/internal/ast"
+	"gox/internal/decimal"
+	"io"
+	"strings"
+)
+
+// EvaluateConstant evaluates expression that does not depend on any variable,
+// e.g. arithmetic on literals. ok is false if the evaluation fails, in which case
+// the expression has to be left for the interpreter to report the error.
+func EvaluateConstant(expr ast.Expr) (value any, ok bool) {
+	interpreter := NewInterpreterWithIO(strings.NewReader(""), io.Discard, io.Discard)
+	// result of decimal division depends on context the program runs with
+	interpreter.Decimal = decimal.Context{}
+	defer func() {
+		if recover() != nil {
+			value, ok = nil, false
+		}
+	}()
+	res, err := interpreter.evaluate(expr)
+	if err != nil {
+		return nil, false
+	}
+	return res, true
+}
+
+// IsTruthy reports whether value is considered true by conditions and logical operators
+func IsTruthy(right any) bool {
+	if right == nil {
+		return false
+	}
+	if _, ok := right.(bool); ok {
+		return right.(bool)
+	} else {
+		if _, ok := right.(string); ok {
+			return len(right.(string)) > 0
+		}
+	}
+	return false
+}
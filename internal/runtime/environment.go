@@ -4,6 +4,7 @@ import (
 	"errors"
 	"gox/internal"
 	"gox/internal/scanning"
+	"sync"
 )
 
 var (
@@ -12,6 +13,7 @@ var (
 
 type environment struct {
 	enclosing *environment
+	mutex     sync.RWMutex // environments are shared by tasks running concurrently
 	values    map[string]any
 }
 
@@ -23,11 +25,15 @@ func newEnvironment(encosing *environment) *environment {
 }
 
 func (r *environment) define(name string, value any) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
 	r.values[name] = value
 }
 
 func (r *environment) get(token *scanning.Token) (any, *internal.RuntimeError) {
+	r.mutex.RLock()
 	val, ok := r.values[token.Lexeme]
+	r.mutex.RUnlock()
 	if ok {
 		return val, nil
 	}
@@ -41,14 +47,33 @@ func (r *environment) get(token *scanning.Token) (any, *internal.RuntimeError) {
 }
 
 func (r *environment) assign(token *scanning.Token, value any) *internal.RuntimeError {
-	if _, ok := r.values[token.Lexeme]; ok {
+	_, _, err := r.update(token, func(any) (any, *internal.RuntimeError) {
+		return value, nil
+	})
+	return err
+}
+
+// update replaces value of variable with result of change applied to it, no other task
+// can access the variable meanwhile. Both the old and the new value are returned.
+func (r *environment) update(
+	token *scanning.Token,
+	change func(old any) (any, *internal.RuntimeError),
+) (any, any, *internal.RuntimeError) {
+	r.mutex.Lock()
+	if old, ok := r.values[token.Lexeme]; ok {
+		defer r.mutex.Unlock()
+		value, err := change(old)
+		if err != nil {
+			return nil, nil, err
+		}
 		r.values[token.Lexeme] = value
-		return nil
+		return old, value, nil
 	}
+	r.mutex.Unlock()
 	if r.enclosing != nil {
-		return r.enclosing.assign(token, value)
+		return r.enclosing.update(token, change)
 	}
-	return &internal.RuntimeError{
+	return nil, nil, &internal.RuntimeError{
 		Error: undefinedVariable,
 		Token: token,
 	}
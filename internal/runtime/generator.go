@@ -0,0 +1,152 @@
+package runtime
+
+import (
+	"errors"
+	"fmt"
+	"gox/internal"
+	goruntime "runtime"
+)
+
+var (
+	// generatorStopped unwinds body of generator which was garbage collected while suspended
+	generatorStopped = errors.New("generator stopped")
+	generatorRunning = errors.New("generator is already running")
+)
+
+// Generator is the result of calling generator function. Its body runs on a goroutine
+// of its own, which is suspended at each yield until the next value is requested.
+type Generator struct {
+	state *generatorState
+}
+
+// generatorState is shared with the goroutine running the body. The goroutine must not
+// reference Generator, so that abandoned generator can be collected and its goroutine stopped.
+type generatorState struct {
+	// running is set while a task runs the body, other tasks resuming the generator
+	// wait for it as waiters, guarded by mutex of the scheduler
+	running  bool
+	waiters  []*waiter
+	function *LoxFunction
+	args     []any
+	started  bool
+	done     bool
+	resume   chan struct{}
+	results  chan generatorResult
+	stop     chan struct{} // closed once the generator is collected
+}
+
+// generatorResult is a yielded value, or the end of the body when done is set
+type generatorResult struct {
+	value any
+	done  bool
+	err   *internal.RuntimeError
+}
+
+func newGenerator(function *LoxFunction, args []any) *Generator {
+	generator := &Generator{state: &generatorState{
+		function: function,
+		args:     args,
+		resume:   make(chan struct{}),
+		results:  make(chan generatorResult),
+		stop:     make(chan struct{}),
+	}}
+	goruntime.SetFinalizer(generator, func(g *Generator) {
+		close(g.state.stop)
+	})
+	return generator
+}
+
+// Next runs body of the generator until it yields a value or ends. Generator resumed
+// by its own body fails, other tasks resuming it wait until it yields.
+func (r *Generator) Next(interpreter *Interpreter) (any, bool, *internal.RuntimeError) {
+	state := r.state
+	if interpreter.generator == state {
+		return nil, false, &internal.RuntimeError{Error: generatorRunning}
+	}
+	s := interpreter.scheduler
+	if err := state.acquire(s); err != nil {
+		return nil, false, &internal.RuntimeError{Error: err}
+	}
+	defer state.release(s)
+	if state.done {
+		return nil, false, nil
+	}
+	if state.started {
+		state.resume <- struct{}{}
+	} else {
+		state.started = true
+		go state.run(interpreter)
+	}
+	res := <-state.results
+	state.done = res.done || res.err != nil
+	return res.value, !state.done, res.err
+}
+
+func (r *Generator) String() string {
+	return "<generator " + r.state.function.declaration.Name.Lexeme + ">"
+}
+
+// acquire waits until no other task runs the body of the generator
+func (r *generatorState) acquire(s *scheduler) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if !r.running {
+		r.running = true
+		return nil
+	}
+	w := newWaiter()
+	r.waiters = append(r.waiters, w)
+	s.block(w)
+	// unless it fails, the waiter is woken by release, which leaves the generator running for it
+	return w.err
+}
+
+// release lets the first task waiting for the generator run its body
+func (r *generatorState) release(s *scheduler) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for len(r.waiters) > 0 {
+		w := r.waiters[0]
+		r.waiters = r.waiters[1:]
+		if s.wake(w, 0, nil, false, nil) {
+			return
+		}
+	}
+	r.running = false
+}
+
+// run executes body of the generator function, it's started by the first call of Next
+func (r *generatorState) run(interpreter *Interpreter) {
+	// the body runs on its own copy of interpreter, so that it keeps its current
+	// environment while suspended and doesn't touch the consumer's one
+	body := *interpreter
+	body.generator = r
+
+	var res generatorResult
+	defer func() {
+		if p := recover(); p != nil {
+			res = generatorResult{err: &internal.RuntimeError{
+				Error: fmt.Errorf("%w: %v", internal.InternalError, p),
+			}}
+		}
+		if res.err != nil && errors.Is(res.err.Error, generatorStopped) {
+			// nobody waits for the result of abandoned generator
+			return
+		}
+		r.results <- res
+	}()
+	_, err := r.function.invoke(&body, r.args)
+	res = generatorResult{done: true, err: err}
+}
+
+// yield passes value to the consumer and waits until it requests the next one,
+// false is returned if the generator was abandoned instead
+func (r *generatorState) yield(value any) bool {
+	r.results <- generatorResult{value: value}
+	select {
+	case <-r.resume:
+		return true
+	case <-r.stop:
+		return false
+	}
+}
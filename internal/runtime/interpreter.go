@@ -5,42 +5,85 @@ import (
 	"fmt"
 	"gox/internal"
 	ast2 "gox/internal/ast"
+	"gox/internal/decimal"
 	"gox/internal/scanning"
+	"io"
+	"math"
+	"os"
+	"strconv"
 	"strings"
 )
 
 // TODO: write tests
 type Interpreter struct {
 	Env *environment
+	// Stdout receives output of print statements
+	Stdout io.Writer
+	// Stderr receives diagnostics reported by the interpreter itself
+	Stderr io.Writer
+	// Stdin is the source of input for the running program
+	Stdin io.Reader
+	// Args are command line arguments passed to the script
+	Args []string
+	// Decimal configures rounding of decimal division
+	Decimal decimal.Context
+	// generator whose body is being executed, nil outside of generators
+	generator *generatorState
+	// scheduler is shared by all tasks of the program
+	scheduler *scheduler
 }
 
+// NewInterpreter creates interpreter bound to standard streams of the process
 func NewInterpreter() *Interpreter {
+	return NewInterpreterWithIO(os.Stdin, os.Stdout, os.Stderr)
+}
+
+// NewInterpreterWithIO creates interpreter reading input from stdin and writing
+// program output and diagnostics to stdout and stderr respectively
+func NewInterpreterWithIO(stdin io.Reader, stdout, stderr io.Writer) *Interpreter {
 	glob := newEnvironment(nil)
 	for _, fn := range StdFunctions {
 		glob.define(fn.Name(), fn)
 	}
 
 	return &Interpreter{
-		Env: glob,
+		Env:       glob,
+		Stdout:    stdout,
+		Stderr:    stderr,
+		Stdin:     stdin,
+		Decimal:   decimal.DefaultContext,
+		scheduler: newScheduler(),
 	}
 }
 
-func (r *Interpreter) Interpret(statements []*ast2.Stmt) *internal.RuntimeError {
+// Interpret executes statements one by one until one fails. Panic caused by a bug
+// in the interpreter is returned as RuntimeError wrapping internal.InternalError
+// with no token, as it can't be attributed to any part of the program.
+func (r *Interpreter) Interpret(statements []*ast2.Stmt) (err *internal.RuntimeError) {
 	defer func() {
-		if err := recover(); err != nil {
-			fmt.Printf("unable to interpret given code: %s", err)
+		if p := recover(); p != nil {
+			err = &internal.RuntimeError{
+				Error: fmt.Errorf("%w: %v", internal.InternalError, p),
+			}
 		}
 	}()
 	for _, stmt := range statements {
-		if stmt == nil {
+		if stmt == nil || *stmt == nil {
 			continue
 		}
-		err := r.execute(*stmt)
+		completion, err := r.execute(*stmt)
 		if err != nil {
 			return err
 		}
+		if completion.Flow != internal.Normal {
+			// parser accepts return, break and continue only inside functions and loops
+			return &internal.RuntimeError{
+				Error: fmt.Errorf("%w: statement completed abruptly on top level", internal.InternalError),
+			}
+		}
 	}
-	return nil
+	// the program ends once all tasks it spawned end
+	return r.scheduler.join()
 }
 
 // expressions
@@ -58,7 +101,22 @@ func (r *Interpreter) VisitForUnary(expr *ast2.Unary) (any, *internal.RuntimeErr
 	case scanning.BANG:
 		return !r.isTruthy(right), nil
 	case scanning.MINUS:
-		return -right.(float64), r.checkNumberOperand(expr.Operator, right)
+		if err = r.checkNumberOperand(expr.Operator, right); err != nil {
+			return nil, err
+		}
+		res, negateError := negate(right)
+		if negateError != nil {
+			return nil, &internal.RuntimeError{Error: negateError, Token: expr.Operator}
+		}
+		return res, nil
+	case scanning.TILDE:
+		if !isInteger(right) {
+			return nil, &internal.RuntimeError{
+				Error: errors.New("operand must be integer"),
+				Token: expr.Operator,
+			}
+		}
+		return complement(right), nil
 	}
 
 	return nil, nil
@@ -74,72 +132,72 @@ func (r *Interpreter) VisitForBinary(expr *ast2.Binary) (any, *internal.RuntimeE
 		return nil, err
 	}
 
-	switch expr.Operator.TokenType {
-	case scanning.EQUAL_EQUAL:
-		bothOperandsAreSting := checkBothOperandsAreString(left, right)
-		if bothOperandsAreSting {
-			return strings.Compare(left.(string), right.(string)) == 0, nil
-		} else {
-			if err = r.checkNumberOperands(*expr.Operator, left, right); err != nil {
-				return nil, err
-			}
-			return r.isEqual(left, right), nil
-		}
-	case scanning.BANG_EQUAL:
+	return r.binary(expr.Operator, left, right)
+}
+
+// binary applies binary operator to evaluated operands
+func (r *Interpreter) binary(operator *scanning.Token, left, right any) (any, *internal.RuntimeError) {
+	switch operator.TokenType {
+	case scanning.EQUAL_EQUAL, scanning.BANG_EQUAL:
 		bothOperandsAreSting := checkBothOperandsAreString(left, right)
 		if bothOperandsAreSting {
-			return !(strings.Compare(left.(string), right.(string)) == 0), nil
-		} else {
-			if err = r.checkNumberOperands(*expr.Operator, left, right); err != nil {
-				return nil, err
-			}
-			return !r.isEqual(left, right), nil
+			equal := strings.Compare(left.(string), right.(string)) == 0
+			return equal == (operator.TokenType == scanning.EQUAL_EQUAL), nil
 		}
-	case scanning.GREATER:
-		if err = r.checkNumberOperands(*expr.Operator, left, right); err != nil {
+		if err := r.checkNumberOperands(*operator, left, right); err != nil {
 			return nil, err
 		}
-		return left.(float64) > right.(float64), nil
-	case scanning.GREATER_EQUAL:
-		if err = r.checkNumberOperands(*expr.Operator, left, right); err != nil {
-			return nil, err
+		res, compareError := compareNumbers(operator.TokenType, left, right)
+		if compareError != nil {
+			return nil, &internal.RuntimeError{Error: compareError, Token: operator}
 		}
-		return left.(float64) >= right.(float64), nil
-	case scanning.LESS:
-		if err = r.checkNumberOperands(*expr.Operator, left, right); err != nil {
+		return res, nil
+	case scanning.GREATER, scanning.GREATER_EQUAL, scanning.LESS, scanning.LESS_EQUAL:
+		if err := r.checkNumberOperands(*operator, left, right); err != nil {
 			return nil, err
 		}
-		return left.(float64) < right.(float64), nil
-	case scanning.LESS_EQUAL:
-		if err = r.checkNumberOperands(*expr.Operator, left, right); err != nil {
-			return nil, err
+		res, compareError := compareNumbers(operator.TokenType, left, right)
+		if compareError != nil {
+			return nil, &internal.RuntimeError{Error: compareError, Token: operator}
 		}
-		return left.(float64) <= right.(float64), nil
-	case scanning.MINUS:
-		if err = r.checkNumberOperands(*expr.Operator, left, right); err != nil {
-			return nil, err
-		}
-		return left.(float64) - right.(float64), nil
+		return res, nil
 	case scanning.PLUS:
 		bothOperandsAreSting := checkBothOperandsAreString(left, right)
 		if bothOperandsAreSting {
 			return fmt.Sprint(left.(string) + right.(string)), nil
-		} else {
-			if err = r.checkNumberOperands(*expr.Operator, left, right); err != nil {
-				return nil, err
-			}
-			return left.(float64) + right.(float64), nil
 		}
-	case scanning.SLASH:
-		if err = r.checkNumberOperands(*expr.Operator, left, right); err != nil {
+		fallthrough
+	case scanning.MINUS, scanning.SLASH, scanning.STAR, scanning.TILDE_SLASH, scanning.PERCENT, scanning.STAR_STAR:
+		if err := r.checkNumberOperands(*operator, left, right); err != nil {
 			return nil, err
 		}
-		return left.(float64) / right.(float64), nil
-	case scanning.STAR:
-		if err = r.checkNumberOperands(*expr.Operator, left, right); err != nil {
-			return nil, err
+		res, arithmeticError := arithmetic(operator.TokenType, left, right, r.Decimal)
+		if arithmeticError != nil {
+			return nil, &internal.RuntimeError{Error: arithmeticError, Token: operator}
+		}
+		return res, nil
+	case scanning.DOT_DOT, scanning.DOT_DOT_EQUAL:
+		from, fromOk := left.(int64)
+		to, toOk := right.(int64)
+		if !fromOk || !toOk {
+			return nil, &internal.RuntimeError{
+				Error: errors.New("range bounds must be integers"),
+				Token: operator,
+			}
+		}
+		return &Range{From: from, To: to, Inclusive: operator.TokenType == scanning.DOT_DOT_EQUAL}, nil
+	case scanning.AMPERSAND, scanning.PIPE, scanning.CARET, scanning.LESS_LESS, scanning.GREATER_GREATER:
+		if !isInteger(left) || !isInteger(right) {
+			return nil, &internal.RuntimeError{
+				Error: errors.New("both operands must be integers"),
+				Token: operator,
+			}
+		}
+		res, bitwiseError := bitwise(operator.TokenType, left, right)
+		if bitwiseError != nil {
+			return nil, &internal.RuntimeError{Error: bitwiseError, Token: operator}
 		}
-		return left.(float64) * right.(float64), nil
+		return res, nil
 	}
 	return nil, nil
 }
@@ -161,10 +219,23 @@ func (r *Interpreter) VisitForAssignExpression(expr *ast2.Assign) (any, *interna
 	if err != nil {
 		return nil, err
 	}
-	err = r.Env.assign(expr.Name, val)
+	if expr.Operator == nil {
+		if err = r.Env.assign(expr.Name, val); err != nil {
+			return nil, err
+		}
+		return val, nil
+	}
+
+	// compound assignment is atomic, so tasks incrementing a shared counter don't lose updates
+	old, val, err := r.Env.update(expr.Name, func(old any) (any, *internal.RuntimeError) {
+		return r.binary(expr.Operator, old, val)
+	})
 	if err != nil {
 		return nil, err
 	}
+	if expr.Postfix {
+		return old, nil
+	}
 	return val, nil
 }
 
@@ -174,11 +245,16 @@ func (r *Interpreter) VisitForLogical(expr *ast2.Logical) (any, *internal.Runtim
 		return nil, err
 	}
 
-	if expr.Operator.TokenType == scanning.OR {
+	switch expr.Operator.TokenType {
+	case scanning.OR:
 		if r.isTruthy(left) {
 			return left, nil
 		}
-	} else {
+	case scanning.QUESTION_QUESTION:
+		if left != nil {
+			return left, nil
+		}
+	default:
 		if !r.isTruthy(left) {
 			return left, nil
 		}
@@ -187,151 +263,494 @@ func (r *Interpreter) VisitForLogical(expr *ast2.Logical) (any, *internal.Runtim
 	return r.evaluate(expr.Right)
 }
 
+func (r *Interpreter) VisitForConditional(expr *ast2.Conditional) (any, *internal.RuntimeError) {
+	condition, err := r.evaluate(expr.Condition)
+	if err != nil {
+		return nil, err
+	}
+	if r.isTruthy(condition) {
+		return r.evaluate(expr.Then)
+	}
+	return r.evaluate(expr.Else)
+}
+
 func (r *Interpreter) VisitForFunctionCall(call *ast2.Call) (any, *internal.RuntimeError) {
+	callee, args, err := r.evaluateCall(call)
+	if err != nil {
+		return nil, err
+	}
+	return r.call(call, callee, args)
+}
+
+// evaluateCall evaluates callee and arguments of call without calling it
+func (r *Interpreter) evaluateCall(call *ast2.Call) (any, []any, *internal.RuntimeError) {
 	callee, runtimeError := r.evaluate(call.Callee)
 	if runtimeError != nil {
-		return nil, runtimeError
+		return nil, nil, runtimeError
 	}
 
 	args := make([]any, len(call.Params))
 	for i, param := range call.Params {
 		val, err := r.evaluate(param)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 		args[i] = val
 	}
+	if len(call.Named) > 0 {
+		args, runtimeError = r.bindNamed(call, callee, args)
+		if runtimeError != nil {
+			return nil, nil, runtimeError
+		}
+	}
+	return callee, args, nil
+}
 
-	_, isStdFunc := callee.(LoxStdFunction)
-	_, isLoxFunc := callee.(LoxFunction)
-	if !isStdFunc && !isLoxFunc {
+// bindNamed places arguments passed by name at positions of parameters of the
+// same name, positions of omitted optional parameters are filled by missingArgument
+func (r *Interpreter) bindNamed(call *ast2.Call, callee any, args []any) ([]any, *internal.RuntimeError) {
+	function, ok := callee.(NamedParams)
+	if !ok {
 		return nil, &internal.RuntimeError{
-			Error: errors.New("non-callable element"),
+			Error: errors.New("function doesn't accept named arguments"),
 			Token: call.Paren,
 		}
 	}
+	names := function.ParamNames()
+	for _, arg := range call.Named {
+		index := indexOf(names, arg.Name.Lexeme)
+		if index < 0 {
+			return nil, &internal.RuntimeError{
+				Error: fmt.Errorf("unknown parameter %s", arg.Name.Lexeme),
+				Token: arg.Name,
+			}
+		}
+		if index < len(args) && args[index] != missingArgument {
+			return nil, &internal.RuntimeError{
+				Error: fmt.Errorf("parameter %s is passed more than once", arg.Name.Lexeme),
+				Token: arg.Name,
+			}
+		}
+		value, err := r.evaluate(arg.Value)
+		if err != nil {
+			return nil, err
+		}
+		for len(args) <= index {
+			args = append(args, missingArgument)
+		}
+		args[index] = value
+	}
+
+	required, _ := function.Arity()
+	for i := 0; i < required && i < len(args); i++ {
+		if args[i] == missingArgument {
+			return nil, &internal.RuntimeError{
+				Error: fmt.Errorf("missing argument for parameter %s", names[i]),
+				Token: call.Paren,
+			}
+		}
+	}
+	return args, nil
+}
 
-	// arity check
-	if len(args) != len(call.Params) {
+func (r *Interpreter) call(call *ast2.Call, callee any, args []any) (any, *internal.RuntimeError) {
+	function, err := r.callable(call, callee, args)
+	if err != nil {
+		return nil, err
+	}
+	res, err := function.Call(r, args)
+	if err != nil && err.Token == nil {
+		// natives don't know where they were called from
+		err.Token = call.Paren
+	}
+	return res, err
+}
+
+// callable returns callee if it can be called with given arguments
+func (r *Interpreter) callable(call *ast2.Call, callee any, args []any) (Callable, *internal.RuntimeError) {
+	function, ok := callee.(Callable)
+	if !ok {
 		return nil, &internal.RuntimeError{
-			Error: errors.New("invalid number of arguments"),
+			Error: errors.New("can only call functions"),
 			Token: call.Paren,
 		}
 	}
-
-	// TODO: this is shit, find a better, idiomatic way
-	if isStdFunc {
-		return callee.(LoxStdFunction).Call(r, args)
+	minArgs, maxArgs := function.Arity()
+	if len(args) >= minArgs && (maxArgs == Variadic || len(args) <= maxArgs) {
+		return function, nil
 	}
-	if isLoxFunc {
-		// TODO: I dont want to assign to variable, I just want to call "Call" method
-		function := callee.(LoxFunction)
-		return function.Call(r, args)
+	var expected string
+	// the noun follows the last number, so it's "at least 1 argument" but "0 to 1 arguments"
+	noun := "arguments"
+	switch {
+	case minArgs == maxArgs:
+		expected = fmt.Sprint(minArgs)
+	case maxArgs == Variadic:
+		expected = fmt.Sprintf("at least %d", minArgs)
+	default:
+		expected = fmt.Sprintf("%d to %d", minArgs, maxArgs)
+	}
+	if minArgs == 1 && (maxArgs == 1 || maxArgs == Variadic) {
+		noun = "argument"
+	}
+	return nil, &internal.RuntimeError{
+		Error: fmt.Errorf("expected %s %s but got %d", expected, noun, len(args)),
+		Token: call.Paren,
 	}
-	return nil, nil
 }
 
 // statements
-func (r *Interpreter) VisitForExpression(stmt *ast2.Expression) *internal.RuntimeError {
+func (r *Interpreter) VisitForExpression(stmt *ast2.Expression) (internal.Completion, *internal.RuntimeError) {
 	_, err := r.evaluate(*stmt.Expression)
-	return err
+	return internal.NormalCompletion, err
 }
 
-func (r *Interpreter) VisitForPrint(stmt *ast2.Print) *internal.RuntimeError {
+func (r *Interpreter) VisitForPrint(stmt *ast2.Print) (internal.Completion, *internal.RuntimeError) {
 	value, err := r.evaluate(*stmt.Expression)
 	if err == nil {
-		fmt.Println(toString(value))
+		_, _ = fmt.Fprintln(r.Stdout, toString(value))
 	}
-	return err
+	return internal.NormalCompletion, err
 }
 
-func (r *Interpreter) VisitForVar(stmt *ast2.Var) *internal.RuntimeError {
+func (r *Interpreter) VisitForVar(stmt *ast2.Var) (internal.Completion, *internal.RuntimeError) {
 	if stmt.Initializer != nil {
 		value, err := r.evaluate(*stmt.Initializer)
 		if err != nil {
-			return err
+			return internal.NormalCompletion, err
 		}
 		r.Env.define(stmt.Name.Lexeme, value)
 	} else {
 		r.Env.define(stmt.Name.Lexeme, nil)
 	}
-	return nil
+	return internal.NormalCompletion, nil
 }
 
-func (r *Interpreter) VisitForBlock(block *ast2.Block) *internal.RuntimeError {
+func (r *Interpreter) VisitForBlock(block *ast2.Block) (internal.Completion, *internal.RuntimeError) {
 	return r.executeBlock(block.Statements, newEnvironment(r.Env))
 }
 
-func (r *Interpreter) VisitForIf(ifStmt *ast2.If) *internal.RuntimeError {
+func (r *Interpreter) VisitForIf(ifStmt *ast2.If) (internal.Completion, *internal.RuntimeError) {
 	conditionRes, err := r.evaluate(ifStmt.Condition)
 	if err != nil {
-		return err
+		return internal.NormalCompletion, err
 	}
 	if r.isTruthy(conditionRes) {
-		r.execute(ifStmt.Then)
+		return r.execute(ifStmt.Then)
 	} else if ifStmt.Else != nil {
-		r.execute(ifStmt.Else)
+		return r.execute(ifStmt.Else)
+	}
+	return internal.NormalCompletion, nil
+}
+
+func (r *Interpreter) VisitForMatch(stmt *ast2.Match) (internal.Completion, *internal.RuntimeError) {
+	value, err := r.evaluate(stmt.Value)
+	if err != nil {
+		return internal.NormalCompletion, err
+	}
+	for _, c := range stmt.Cases {
+		env := newEnvironment(r.Env)
+		matched := false
+		for _, pattern := range c.Patterns {
+			if matched, err = r.matches(pattern, value, c.Keyword); err != nil {
+				return internal.NormalCompletion, err
+			}
+			if matched {
+				if pattern.Binding != nil && pattern.Binding.Lexeme != "_" {
+					env.define(pattern.Binding.Lexeme, value)
+				}
+				break
+			}
+		}
+		if !matched {
+			continue
+		}
+		if c.Guard != nil {
+			guard, err := r.evaluateIn(c.Guard, env)
+			if err != nil {
+				return internal.NormalCompletion, err
+			}
+			if !r.isTruthy(guard) {
+				continue
+			}
+		}
+		return r.executeBlock([]ast2.Stmt{c.Body}, env)
+	}
+	return internal.NormalCompletion, &internal.RuntimeError{
+		Error: fmt.Errorf("no case matches value %s", toString(value)),
+		Token: stmt.Keyword,
+	}
+}
+
+// matches reports whether value matches pattern of case starting with keyword
+func (r *Interpreter) matches(pattern *ast2.Pattern, value any, keyword *scanning.Token) (bool, *internal.RuntimeError) {
+	if pattern.Value == nil {
+		return true, nil
+	}
+	expected, err := r.evaluate(pattern.Value)
+	if err != nil {
+		return false, err
+	}
+	if pattern.To == nil {
+		equal, equalError := valuesEqual(value, expected)
+		if equalError != nil {
+			return false, &internal.RuntimeError{Error: equalError, Token: keyword}
+		}
+		return equal, nil
+	}
+
+	to, err := r.evaluate(pattern.To)
+	if err != nil {
+		return false, err
+	}
+	if !isNumber(expected) || !isNumber(to) {
+		return false, &internal.RuntimeError{Error: errors.New("range bounds must be numbers"), Token: keyword}
+	}
+	if !isNumber(value) {
+		return false, nil
+	}
+	upper := scanning.LESS
+	if pattern.Inclusive {
+		upper = scanning.LESS_EQUAL
+	}
+	above, compareError := compareNumbers(scanning.GREATER_EQUAL, value, expected)
+	if compareError == nil && above {
+		var below bool
+		below, compareError = compareNumbers(upper, value, to)
+		above = above && below
+	}
+	if compareError != nil {
+		return false, &internal.RuntimeError{Error: compareError, Token: keyword}
+	}
+	return above, nil
+}
+
+// valuesEqual compares values of any type, values of different types are not equal
+func valuesEqual(a, b any) (bool, error) {
+	if isNumber(a) && isNumber(b) {
+		return compareNumbers(scanning.EQUAL_EQUAL, a, b)
+	}
+	if isNumber(a) || isNumber(b) {
+		return false, nil
+	}
+	return a == b, nil
+}
+
+func (r *Interpreter) VisitForWhile(while *ast2.While) (internal.Completion, *internal.RuntimeError) {
+	for {
+		conditionRes, err := r.evaluate(while.Condition)
+		if err != nil {
+			return internal.NormalCompletion, err
+		}
+		if !r.isTruthy(conditionRes) {
+			return internal.NormalCompletion, nil
+		}
+
+		completion, err := r.execute(while.Statement)
+		if err != nil {
+			return completion, err
+		}
+		switch completion.Flow {
+		case internal.Return:
+			return completion, nil
+		case internal.Break:
+			return internal.NormalCompletion, nil
+		}
+
+		if while.Increment != nil {
+			if _, err := r.evaluate(while.Increment); err != nil {
+				return internal.NormalCompletion, err
+			}
+		}
 	}
-	return nil
 }
 
-func (r *Interpreter) VisitForWhile(while *ast2.While) *internal.RuntimeError {
-	conditionRes, err := r.evaluate(while.Condition)
+func (r *Interpreter) VisitForForIn(stmt *ast2.ForIn) (internal.Completion, *internal.RuntimeError) {
+	iterable, err := r.evaluate(stmt.Iterable)
 	if err != nil {
-		return err
+		return internal.NormalCompletion, err
+	}
+	iterator, iterateError := iterate(iterable)
+	if iterateError != nil {
+		return internal.NormalCompletion, &internal.RuntimeError{Error: iterateError, Token: stmt.In}
 	}
-	for r.isTruthy(conditionRes) {
-		err := r.execute(while.Statement)
+	for {
+		value, ok, err := iterator.Next(r)
 		if err != nil {
-			return err
+			if err.Token == nil {
+				err.Token = stmt.In
+			}
+			return internal.NormalCompletion, err
+		}
+		if !ok {
+			return internal.NormalCompletion, nil
 		}
-		conditionRes, err = r.evaluate(while.Condition)
+
+		// each iteration has its own variable, so closures capture the current value
+		env := newEnvironment(r.Env)
+		env.define(stmt.Name.Lexeme, value)
+		completion, err := r.executeBlock([]ast2.Stmt{stmt.Body}, env)
 		if err != nil {
-			return err
+			return completion, err
+		}
+		switch completion.Flow {
+		case internal.Return:
+			return completion, nil
+		case internal.Break:
+			return internal.NormalCompletion, nil
 		}
 	}
-	return nil
 }
 
-func (r *Interpreter) VisitForFunction(function *ast2.Function) *internal.RuntimeError {
-	fun := LoxFunction{declaration: *function}
-	r.Env.define(function.Name.Lexeme, fun)
-	return nil
+func (r *Interpreter) VisitForYield(stmt *ast2.Yield) (internal.Completion, *internal.RuntimeError) {
+	var value any
+	if stmt.Value != nil {
+		var err *internal.RuntimeError
+		if value, err = r.evaluate(stmt.Value); err != nil {
+			return internal.NormalCompletion, err
+		}
+	}
+	if !r.generator.yield(value) {
+		return internal.NormalCompletion, &internal.RuntimeError{Error: generatorStopped, Token: stmt.Keyword}
+	}
+	return internal.NormalCompletion, nil
 }
 
-func (r *Interpreter) VisitForReturn(ret *ast2.Return) *internal.RuntimeError {
-	res, err := r.evaluate(ret.Value)
+func (r *Interpreter) VisitForSpawn(expr *ast2.Spawn) (any, *internal.RuntimeError) {
+	callee, args, err := r.evaluateCall(expr.Call)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	function, err := r.callable(expr.Call, callee, args)
+	if err != nil {
+		return nil, err
 	}
-	// TODO: make type for Return panic
-	panic(res)
+	return spawn(r, function, args), nil
 }
 
-func (r *Interpreter) evaluate(expr ast2.Expr) (any, *internal.RuntimeError) {
-	return expr.Accept(r)
-}
+func (r *Interpreter) VisitForSelect(stmt *ast2.Select) (internal.Completion, *internal.RuntimeError) {
+	operations := make([]channelOperation, 0, len(stmt.Cases))
+	// cases holds index of the case of each operation
+	cases := make([]int, 0, len(stmt.Cases))
+	defaultCase := -1
+	for i, c := range stmt.Cases {
+		if c.Channel == nil {
+			defaultCase = i
+			continue
+		}
+		value, err := r.evaluate(c.Channel)
+		if err != nil {
+			return internal.NormalCompletion, err
+		}
+		ch, ok := value.(*Channel)
+		if !ok {
+			return internal.NormalCompletion, &internal.RuntimeError{
+				Error: errors.New("select case requires a channel"),
+				Token: c.Keyword,
+			}
+		}
+		operation := channelOperation{channel: ch, receive: c.Value == nil}
+		if c.Value != nil {
+			if operation.value, err = r.evaluate(c.Value); err != nil {
+				return internal.NormalCompletion, err
+			}
+		}
+		operations = append(operations, operation)
+		cases = append(cases, i)
+	}
 
-func (r *Interpreter) isTruthy(right any) bool {
-	if right == nil {
-		return false
+	chosen, received, err := r.scheduler.selectOperation(operations, defaultCase < 0)
+	if err != nil {
+		token := stmt.Keyword
+		if chosen >= 0 {
+			token = stmt.Cases[cases[chosen]].Keyword
+		}
+		return internal.NormalCompletion, &internal.RuntimeError{Error: err, Token: token}
 	}
-	if _, ok := right.(bool); ok {
-		return right.(bool)
+	if chosen >= 0 {
+		chosen = cases[chosen]
 	} else {
-		if _, ok := right.(string); ok {
-			return len(right.(string)) > 0
+		chosen = defaultCase
+	}
+	c := stmt.Cases[chosen]
+	env := newEnvironment(r.Env)
+	if c.Binding != nil {
+		env.define(c.Binding.Lexeme, received)
+	}
+	return r.executeBlock([]ast2.Stmt{c.Body}, env)
+}
+
+func (r *Interpreter) VisitForInterpolation(expr *ast2.Interpolation) (any, *internal.RuntimeError) {
+	var builder strings.Builder
+	for _, part := range expr.Parts {
+		value, err := r.evaluate(part)
+		if err != nil {
+			return nil, err
+		}
+		builder.WriteString(toString(value))
+	}
+	return builder.String(), nil
+}
+
+func (r *Interpreter) VisitForLambda(lambda *ast2.Lambda) (any, *internal.RuntimeError) {
+	declaration := ast2.Function{Params: lambda.Params, ReturnType: lambda.ReturnType, Body: lambda.Body}
+	return &LoxFunction{declaration: declaration, closure: r.Env}, nil
+}
+
+func (r *Interpreter) VisitForFunction(function *ast2.Function) (internal.Completion, *internal.RuntimeError) {
+	fun := &LoxFunction{declaration: *function, closure: r.Env}
+	r.Env.define(function.Name.Lexeme, fun)
+	return internal.NormalCompletion, nil
+}
+
+func (r *Interpreter) VisitForReturn(ret *ast2.Return) (internal.Completion, *internal.RuntimeError) {
+	if ret.Value == nil {
+		return internal.Completion{Flow: internal.Return}, nil
+	}
+	if call, ok := ret.Value.(*ast2.Call); ok {
+		callee, args, err := r.evaluateCall(call)
+		if err != nil {
+			return internal.NormalCompletion, err
 		}
+		if function, ok := callee.(*LoxFunction); ok && !function.declaration.Generator {
+			if _, err := r.callable(call, function, args); err != nil {
+				return internal.NormalCompletion, err
+			}
+			// let the caller run the function in place of the one returning
+			return internal.Completion{Flow: internal.Return, Value: &tailCall{function: function, args: args}}, nil
+		}
+		res, err := r.call(call, callee, args)
+		if err != nil {
+			return internal.NormalCompletion, err
+		}
+		return internal.Completion{Flow: internal.Return, Value: res}, nil
+	}
+
+	res, err := r.evaluate(ret.Value)
+	if err != nil {
+		return internal.NormalCompletion, err
 	}
-	return false
+	return internal.Completion{Flow: internal.Return, Value: res}, nil
 }
 
-func (r *Interpreter) isEqual(a, b any) bool {
-	return (a == nil && b == nil) || (a == b)
+func (r *Interpreter) VisitForBreak(stmt *ast2.Break) (internal.Completion, *internal.RuntimeError) {
+	return internal.Completion{Flow: internal.Break}, nil
+}
+
+func (r *Interpreter) VisitForContinue(stmt *ast2.Continue) (internal.Completion, *internal.RuntimeError) {
+	return internal.Completion{Flow: internal.Continue}, nil
+}
+
+func (r *Interpreter) evaluate(expr ast2.Expr) (any, *internal.RuntimeError) {
+	return expr.Accept(r)
+}
+
+func (r *Interpreter) isTruthy(right any) bool {
+	return IsTruthy(right)
 }
 
 func (r *Interpreter) checkNumberOperand(operator *scanning.Token, operand any) *internal.RuntimeError {
-	if _, ok := operand.(float64); ok {
+	if isNumber(operand) {
 		return nil
 	}
 	return &internal.RuntimeError{
@@ -348,10 +767,8 @@ func checkBothOperandsAreString(left any, right any) bool {
 }
 
 func (r *Interpreter) checkNumberOperands(operator scanning.Token, operand1, operand2 any) *internal.RuntimeError {
-	if _, ok := operand1.(float64); ok {
-		if _, ok := operand2.(float64); ok {
-			return nil
-		}
+	if isNumber(operand1) && isNumber(operand2) {
+		return nil
 	}
 	return &internal.RuntimeError{
 		Error: errors.New("both operands must be numbers"),
@@ -359,11 +776,23 @@ func (r *Interpreter) checkNumberOperands(operator scanning.Token, operand1, ope
 	}
 }
 
-func (r *Interpreter) execute(stmt ast2.Stmt) *internal.RuntimeError {
+func (r *Interpreter) execute(stmt ast2.Stmt) (internal.Completion, *internal.RuntimeError) {
 	return stmt.Accept(r)
 }
 
-func (r *Interpreter) executeBlock(statements []ast2.Stmt, env *environment) *internal.RuntimeError {
+// evaluateIn evaluates expression in given environment
+func (r *Interpreter) evaluateIn(expr ast2.Expr, env *environment) (any, *internal.RuntimeError) {
+	prevEnv := r.Env
+	defer func() {
+		r.Env = prevEnv
+	}()
+	r.Env = env
+	return r.evaluate(expr)
+}
+
+// executeBlock executes statements in env until one of them completes abruptly,
+// e.g. by return, and passes such completion to the enclosing statement
+func (r *Interpreter) executeBlock(statements []ast2.Stmt, env *environment) (internal.Completion, *internal.RuntimeError) {
 	prevEnv := r.Env
 
 	// after execution
@@ -374,15 +803,35 @@ func (r *Interpreter) executeBlock(statements []ast2.Stmt, env *environment) *in
 	r.Env = env
 
 	for _, statement := range statements {
-		err := r.execute(statement)
-		if err != nil {
-			return err
+		completion, err := r.execute(statement)
+		if err != nil || completion.Flow != internal.Normal {
+			return completion, err
 		}
 	}
 
-	return nil
+	return internal.NormalCompletion, nil
 }
 
+// toString formats value the way print shows it, numbers without fraction are
+// printed as integers
 func toString(a any) string {
+	switch value := a.(type) {
+	case nil:
+		return "nil"
+	case float64:
+		if math.Abs(value) < 1e21 {
+			return strconv.FormatFloat(value, 'f', -1, 64)
+		}
+		return strconv.FormatFloat(value, 'g', -1, 64)
+	}
 	return fmt.Sprint(a)
 }
+
+func indexOf(names []string, name string) int {
+	for i, n := range names {
+		if n == name {
+			return i
+		}
+	}
+	return -1
+}
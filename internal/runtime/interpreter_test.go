@@ -0,0 +1,61 @@
+package runtime
+
+import (
+	"errors"
+	"gox/internal"
+	"gox/internal/ast"
+	"gox/internal/parsing"
+	"gox/internal/scanning"
+	"io"
+	goruntime "runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBugInInterpreterIsReportedAsInternalError(t *testing.T) {
+	interpreter := NewInterpreterWithIO(strings.NewReader(""), io.Discard, io.Discard)
+	// print statement without expression can't be produced by the parser
+	var missing ast.Expr
+	var stmt ast.Stmt = &ast.Print{Expression: &missing}
+
+	err := interpreter.Interpret([]*ast.Stmt{&stmt})
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	if !errors.Is(err.Error, internal.InternalError) {
+		t.Errorf("expected internal error, got %v", err.Error)
+	}
+}
+
+func TestAbandonedGeneratorIsStopped(t *testing.T) {
+	tokens, syntaxErr := scanning.NewLexer(`
+		fun* numbers() { var i = 0; while (true) { yield i; i += 1; } }
+		var gen = numbers();
+		print next(gen);
+		gen = nil;
+	`).ScanTokens()
+	if syntaxErr != nil {
+		t.Fatal(syntaxErr)
+	}
+	statements, parseErr := parsing.NewParser(tokens).Parse()
+	if parseErr != nil {
+		t.Fatal(parseErr)
+	}
+
+	before := goruntime.NumGoroutine()
+	interpreter := NewInterpreterWithIO(strings.NewReader(""), io.Discard, io.Discard)
+	if err := interpreter.Interpret(statements); err != nil {
+		t.Fatal(err.Error)
+	}
+	if goruntime.NumGoroutine() <= before {
+		t.Fatal("expected suspended generator to keep its goroutine")
+	}
+	for i := 0; i < 100 && goruntime.NumGoroutine() > before; i++ {
+		goruntime.GC()
+		time.Sleep(time.Millisecond)
+	}
+	if goruntime.NumGoroutine() > before {
+		t.Error("goroutine of abandoned generator was not stopped")
+	}
+}
@@ -0,0 +1,167 @@
+package runtime
+
+import (
+	"errors"
+	"fmt"
+	"gox/internal"
+	"math"
+)
+
+var notIterable = errors.New("value is not iterable")
+
+// Iterator produces values iterated by for-in loop one by one
+type Iterator interface {
+	// Next returns next value and true, or false once there are no more values
+	Next(interpreter *Interpreter) (any, bool, *internal.RuntimeError)
+}
+
+// Iterable is implemented by values which can be iterated by for-in loop
+type Iterable interface {
+	Iterator() Iterator
+}
+
+// iterate returns iterator over value. Besides Iterable values and iterators themselves,
+// such as generators and channels, strings are iterated by characters.
+func iterate(value any) (Iterator, error) {
+	switch v := value.(type) {
+	case Iterator:
+		return v, nil
+	case Iterable:
+		return v.Iterator(), nil
+	case string:
+		return &stringIterator{runes: []rune(v)}, nil
+	}
+	return nil, notIterable
+}
+
+// bufferedIterator is returned by the iterator native. It can tell whether there is
+// a next value by fetching it ahead, which is kept until it's requested.
+type bufferedIterator struct {
+	iterator Iterator
+	buffered bool
+	value    any
+	ok       bool
+}
+
+func (r *bufferedIterator) Next(interpreter *Interpreter) (any, bool, *internal.RuntimeError) {
+	if !r.buffered {
+		return r.iterator.Next(interpreter)
+	}
+	r.buffered = false
+	return r.value, r.ok, nil
+}
+
+// done reports whether there are no more values
+func (r *bufferedIterator) done(interpreter *Interpreter) (bool, *internal.RuntimeError) {
+	if !r.buffered {
+		value, ok, err := r.iterator.Next(interpreter)
+		if err != nil {
+			return false, err
+		}
+		r.buffered, r.value, r.ok = true, value, ok
+	}
+	return !r.ok, nil
+}
+
+func (r *bufferedIterator) String() string {
+	return "<iterator>"
+}
+
+// Range is lazy sequence of integers from From up to To, which is included only if Inclusive is set
+type Range struct {
+	From      int64
+	To        int64
+	Inclusive bool
+}
+
+// Len returns number of integers in range, which fails if it doesn't fit into int64
+func (r *Range) Len() (int64, error) {
+	if r.To < r.From || r.To == r.From && !r.Inclusive {
+		return 0, nil
+	}
+	if r.From < 0 && r.To > math.MaxInt64+r.From {
+		return 0, integerOverflow
+	}
+	n := r.To - r.From
+	if r.Inclusive {
+		if n == math.MaxInt64 {
+			return 0, integerOverflow
+		}
+		n++
+	}
+	return n, nil
+}
+
+func (r *Range) Iterator() Iterator {
+	return &rangeIterator{next: r.From, to: r.To, inclusive: r.Inclusive}
+}
+
+func (r *Range) String() string {
+	if r.Inclusive {
+		return fmt.Sprintf("%d..=%d", r.From, r.To)
+	}
+	return fmt.Sprintf("%d..%d", r.From, r.To)
+}
+
+type rangeIterator struct {
+	next      int64
+	to        int64
+	inclusive bool
+	done      bool
+}
+
+func (r *rangeIterator) Next(*Interpreter) (any, bool, *internal.RuntimeError) {
+	if r.done || r.next > r.to || r.next == r.to && !r.inclusive {
+		return nil, false, nil
+	}
+	value := r.next
+	// next can't be incremented past math.MaxInt64, which ends inclusive range
+	if value == math.MaxInt64 {
+		r.done = true
+	} else {
+		r.next++
+	}
+	return value, true, nil
+}
+
+func (r *rangeIterator) String() string {
+	return "<iterator>"
+}
+
+func (r *List) Iterator() Iterator {
+	return &listIterator{list: r}
+}
+
+type listIterator struct {
+	list  *List
+	index int
+}
+
+func (r *listIterator) Next(*Interpreter) (any, bool, *internal.RuntimeError) {
+	element, ok := r.list.Get(r.index)
+	if ok {
+		r.index++
+	}
+	return element, ok, nil
+}
+
+func (r *listIterator) String() string {
+	return "<iterator>"
+}
+
+type stringIterator struct {
+	runes []rune
+	index int
+}
+
+func (r *stringIterator) Next(*Interpreter) (any, bool, *internal.RuntimeError) {
+	if r.index >= len(r.runes) {
+		return nil, false, nil
+	}
+	r.index++
+	return string(r.runes[r.index-1]), true, nil
+}
+
+func (r *stringIterator) String() string {
+	return "<iterator>"
+}
@@ -0,0 +1,37 @@
+package runtime
+
+import "strings"
+
+// List is an immutable ordered sequence of values
+type List struct {
+	elements []any
+}
+
+func NewList(elements []any) *List {
+	return &List{elements: elements}
+}
+
+func (r *List) Len() int {
+	return len(r.elements)
+}
+
+// Get returns element at index and whether the index was within bounds
+func (r *List) Get(index int) (any, bool) {
+	if index < 0 || index >= len(r.elements) {
+		return nil, false
+	}
+	return r.elements[index], true
+}
+
+func (r *List) String() string {
+	var sb strings.Builder
+	sb.WriteString("[")
+	for i, element := range r.elements {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		sb.WriteString(toString(element))
+	}
+	sb.WriteString("]")
+	return sb.String()
+}
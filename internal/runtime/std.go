@@ -1,15 +1,34 @@
 package runtime
 
 import (
+	"errors"
 	"gox/internal"
 	"time"
 )
 
+var (
+	invalidArgument = errors.New("invalid argument")
+	indexOutOfRange = errors.New("index out of range")
+)
+
 var StdFunctions []LoxStdFunction
 
 func init() {
-	StdFunctions = make([]LoxStdFunction, 1)
-	StdFunctions[0] = &clock{}
+	StdFunctions = []LoxStdFunction{
+		&clock{},
+		&scriptArgs{},
+		&length{},
+		&get{},
+		&list{},
+		&iterator{},
+		&next{},
+		&done{},
+		&channel{},
+		&send{},
+		&recv{},
+		&closeChannel{},
+		&wait{},
+	}
 }
 
 type clock struct {
@@ -19,10 +38,291 @@ func (c *clock) Name() string {
 	return "clock"
 }
 
-func (c *clock) Arity() int {
-	return 0
+func (c *clock) Arity() (int, int) {
+	return 0, 0
 }
 
 func (c *clock) Call(interpreter *Interpreter, args []any) (any, *internal.RuntimeError) {
 	return time.Now().Unix(), nil
 }
+
+// scriptArgs returns list of arguments passed to the script on command line
+type scriptArgs struct {
+}
+
+func (c *scriptArgs) Name() string {
+	return "args"
+}
+
+func (c *scriptArgs) Arity() (int, int) {
+	return 0, 0
+}
+
+func (c *scriptArgs) Call(interpreter *Interpreter, args []any) (any, *internal.RuntimeError) {
+	elements := make([]any, len(interpreter.Args))
+	for i, arg := range interpreter.Args {
+		elements[i] = arg
+	}
+	return NewList(elements), nil
+}
+
+// length returns number of elements of list or range, or number of bytes of string
+type length struct {
+}
+
+func (c *length) Name() string {
+	return "len"
+}
+
+func (c *length) Arity() (int, int) {
+	return 1, 1
+}
+
+func (c *length) ParamNames() []string {
+	return []string{"value"}
+}
+
+func (c *length) Call(interpreter *Interpreter, args []any) (any, *internal.RuntimeError) {
+	switch value := args[0].(type) {
+	case *List:
+		return int64(value.Len()), nil
+	case string:
+		return int64(len(value)), nil
+	case *Range:
+		n, err := value.Len()
+		if err != nil {
+			return nil, &internal.RuntimeError{Error: err}
+		}
+		return n, nil
+	}
+	return nil, &internal.RuntimeError{Error: invalidArgument}
+}
+
+// get returns element of list at given index
+type get struct {
+}
+
+func (c *get) Name() string {
+	return "get"
+}
+
+func (c *get) Arity() (int, int) {
+	return 2, 2
+}
+
+func (c *get) ParamNames() []string {
+	return []string{"list", "index"}
+}
+
+func (c *get) Call(interpreter *Interpreter, args []any) (any, *internal.RuntimeError) {
+	list, ok := args[0].(*List)
+	if !ok {
+		return nil, &internal.RuntimeError{Error: invalidArgument}
+	}
+	index, ok := toInteger(args[1])
+	if !ok {
+		return nil, &internal.RuntimeError{Error: invalidArgument}
+	}
+	element, ok := list.Get(int(index))
+	if !ok {
+		return nil, &internal.RuntimeError{Error: indexOutOfRange}
+	}
+	return element, nil
+}
+
+// list returns list of its arguments
+type list struct {
+}
+
+func (c *list) Name() string {
+	return "list"
+}
+
+func (c *list) Arity() (int, int) {
+	return 0, Variadic
+}
+
+func (c *list) Call(interpreter *Interpreter, args []any) (any, *internal.RuntimeError) {
+	elements := make([]any, len(args))
+	copy(elements, args)
+	return NewList(elements), nil
+}
+
+// iterator returns iterator over value, which produces the values for-in loop would iterate
+type iterator struct {
+}
+
+func (c *iterator) Name() string {
+	return "iterator"
+}
+
+func (c *iterator) Arity() (int, int) {
+	return 1, 1
+}
+
+func (c *iterator) Call(interpreter *Interpreter, args []any) (any, *internal.RuntimeError) {
+	res, err := iterate(args[0])
+	if err != nil {
+		return nil, &internal.RuntimeError{Error: err}
+	}
+	return &bufferedIterator{iterator: res}, nil
+}
+
+// next returns next value of iterator, nil once there are no more values
+type next struct {
+}
+
+func (c *next) Name() string {
+	return "next"
+}
+
+func (c *next) Arity() (int, int) {
+	return 1, 1
+}
+
+func (c *next) Call(interpreter *Interpreter, args []any) (any, *internal.RuntimeError) {
+	it, ok := args[0].(Iterator)
+	if !ok {
+		return nil, &internal.RuntimeError{Error: invalidArgument}
+	}
+	value, _, err := it.Next(interpreter)
+	return value, err
+}
+
+// done reports whether iterator returned by iterator native has no more values,
+// unlike nil returned by next it can't be confused with a nil value
+type done struct {
+}
+
+func (c *done) Name() string {
+	return "done"
+}
+
+func (c *done) Arity() (int, int) {
+	return 1, 1
+}
+
+func (c *done) Call(interpreter *Interpreter, args []any) (any, *internal.RuntimeError) {
+	it, ok := args[0].(*bufferedIterator)
+	if !ok {
+		return nil, &internal.RuntimeError{Error: invalidArgument}
+	}
+	return it.done(interpreter)
+}
+
+// channel returns new channel with buffer of given capacity, unbuffered by default
+type channel struct {
+}
+
+func (c *channel) Name() string {
+	return "channel"
+}
+
+func (c *channel) Arity() (int, int) {
+	return 0, 1
+}
+
+func (c *channel) ParamNames() []string {
+	return []string{"capacity"}
+}
+
+func (c *channel) Call(interpreter *Interpreter, args []any) (any, *internal.RuntimeError) {
+	if len(args) == 0 {
+		return newChannel(interpreter.scheduler, 0), nil
+	}
+	capacity, ok := toInteger(args[0])
+	if !ok || capacity < 0 {
+		return nil, &internal.RuntimeError{Error: invalidArgument}
+	}
+	return newChannel(interpreter.scheduler, int(capacity)), nil
+}
+
+// send sends value to channel, blocking until there is room for it
+type send struct {
+}
+
+func (c *send) Name() string {
+	return "send"
+}
+
+func (c *send) Arity() (int, int) {
+	return 2, 2
+}
+
+func (c *send) Call(interpreter *Interpreter, args []any) (any, *internal.RuntimeError) {
+	ch, ok := args[0].(*Channel)
+	if !ok {
+		return nil, &internal.RuntimeError{Error: invalidArgument}
+	}
+	if err := ch.send(args[1]); err != nil {
+		return nil, &internal.RuntimeError{Error: err}
+	}
+	return nil, nil
+}
+
+// recv returns value received from channel, nil once the channel is closed
+type recv struct {
+}
+
+func (c *recv) Name() string {
+	return "recv"
+}
+
+func (c *recv) Arity() (int, int) {
+	return 1, 1
+}
+
+func (c *recv) Call(interpreter *Interpreter, args []any) (any, *internal.RuntimeError) {
+	ch, ok := args[0].(*Channel)
+	if !ok {
+		return nil, &internal.RuntimeError{Error: invalidArgument}
+	}
+	value, _, err := ch.recv()
+	if err != nil {
+		return nil, &internal.RuntimeError{Error: err}
+	}
+	return value, nil
+}
+
+// closeChannel closes channel, values already sent can still be received
+type closeChannel struct {
+}
+
+func (c *closeChannel) Name() string {
+	return "close"
+}
+
+func (c *closeChannel) Arity() (int, int) {
+	return 1, 1
+}
+
+func (c *closeChannel) Call(interpreter *Interpreter, args []any) (any, *internal.RuntimeError) {
+	ch, ok := args[0].(*Channel)
+	if !ok {
+		return nil, &internal.RuntimeError{Error: invalidArgument}
+	}
+	if err := ch.close(); err != nil {
+		return nil, &internal.RuntimeError{Error: err}
+	}
+	return nil, nil
+}
+
+// wait blocks until spawned task ends and returns its result, failing if the task failed
+type wait struct {
+}
+
+func (c *wait) Name() string {
+	return "wait"
+}
+
+func (c *wait) Arity() (int, int) {
+	return 1, 1
+}
+
+func (c *wait) Call(interpreter *Interpreter, args []any) (any, *internal.RuntimeError) {
+	task, ok := args[0].(*Task)
+	if !ok {
+		return nil, &internal.RuntimeError{Error: invalidArgument}
+	}
+	return task.wait()
+}
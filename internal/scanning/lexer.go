@@ -2,9 +2,13 @@ package scanning
 
 import (
 	"errors"
+	"fmt"
+	"gox/internal/decimal"
+	"math/big"
 	"strconv"
 	"strings"
 	"unicode"
+	"unicode/utf8"
 )
 
 var (
@@ -13,37 +17,60 @@ var (
 	InvalidNumber       = errors.New("invalid number")
 
 	reserved = map[string]TokenType{
-		"and":    AND,
-		"class":  CLASS,
-		"else":   ELSE,
-		"false":  FALSE,
-		"for":    FOR,
-		"fun":    FUN,
-		"if":     IF,
-		"nil":    NIL,
-		"or":     OR,
-		"print":  PRINT,
-		"return": RETURN,
-		"super":  SUPER,
-		"this":   THIS,
-		"true":   TRUE,
-		"var":    VAR,
-		"while":  WHILE,
+		"and":      AND,
+		"break":    BREAK,
+		"case":     CASE,
+		"class":    CLASS,
+		"continue": CONTINUE,
+		"else":     ELSE,
+		"false":    FALSE,
+		"for":      FOR,
+		"fun":      FUN,
+		"if":       IF,
+		"in":       IN,
+		"match":    MATCH,
+		"nil":      NIL,
+		"or":       OR,
+		"print":    PRINT,
+		"return":   RETURN,
+		"select":   SELECT,
+		"spawn":    SPAWN,
+		"super":    SUPER,
+		"this":     THIS,
+		"true":     TRUE,
+		"var":      VAR,
+		"while":    WHILE,
+		"yield":    YIELD,
 	}
 )
 
 type SyntaxError struct {
 	error
+	Line   int
+	Column int // column of the offending character counted from 1, 0 if unknown
+}
+
+// Comment is a single line comment found in source code
+type Comment struct {
+	Text string
 	Line int
 }
 
-// TODO: write tests
 type Lexer struct {
-	Source  string
-	tokens  []Token
-	start   int // start of lexeme
-	current int // current character of lexeme being scanned
-	line    int
+	Source   string
+	tokens   []Token
+	comments []Comment
+	start    int // start of lexeme
+	current  int // current character of lexeme being scanned
+	line     int
+	// lineStart is position of the first character of current line
+	lineStart int
+	// errorAt is position of character causing syntax error if it isn't the
+	// first character of the lexeme, -1 otherwise
+	errorAt int
+	// interpolations holds number of unclosed braces in each expression
+	// interpolated into string literal being scanned, innermost last
+	interpolations []int
 }
 
 func NewLexer(source string) *Lexer {
@@ -55,17 +82,38 @@ func NewLexer(source string) *Lexer {
 
 func (r *Lexer) ScanTokens() ([]Token, *SyntaxError) {
 	var err error
+	r.skipShebang()
 	for {
 		if r.isAtEnd() {
+			if len(r.interpolations) > 0 {
+				return nil, &SyntaxError{
+					error: UnterminatedString,
+					Line:  r.line,
+				}
+			}
 			r.tokens = append(r.tokens, Token{EOF, "", nil, r.line})
 			break
 		}
 		r.start = r.current
+		r.errorAt = -1
+		// token may span lines, so the line it starts at is kept for reporting errors
+		line, lineStart := r.line, r.lineStart
 		err = r.scanToken()
 		if err != nil {
+			position := r.start
+			if r.errorAt >= 0 {
+				position = r.errorAt
+			}
+			for i := lineStart; i < position; i++ {
+				if r.Source[i] == '\n' {
+					line++
+					lineStart = i + 1
+				}
+			}
 			return nil, &SyntaxError{
-				error: err,
-				Line:  r.line,
+				error:  err,
+				Line:   line,
+				Column: utf8.RuneCountInString(r.Source[lineStart:position]) + 1,
 			}
 		}
 	}
@@ -83,41 +131,105 @@ func (r *Lexer) scanToken() error {
 		r.addSimpleToken(RIGHT_PAREN)
 		break
 	case '{':
+		if n := len(r.interpolations); n > 0 {
+			r.interpolations[n-1]++
+		}
 		r.addSimpleToken(LEFT_BRACE)
 		break
 	case '}':
+		if n := len(r.interpolations); n > 0 {
+			if r.interpolations[n-1] == 0 {
+				// end of interpolated expression, the string continues
+				r.interpolations = r.interpolations[:n-1]
+				return r.stringLiteral()
+			}
+			r.interpolations[n-1]--
+		}
 		r.addSimpleToken(RIGHT_BRACE)
 		break
 	case ',':
 		r.addSimpleToken(COMMA)
 		break
 	case '.':
-		r.addSimpleToken(DOT)
+		switch {
+		case r.peek() == "." && r.peekNext() == ".":
+			r.current += 2
+			r.addSimpleToken(ELLIPSIS)
+		case r.match("."):
+			r.addSimpleToken(r.matchReturn("=", DOT_DOT_EQUAL, DOT_DOT))
+		default:
+			r.addSimpleToken(DOT)
+		}
 		break
 	case '-':
-		r.addSimpleToken(MINUS)
+		if r.match("-") {
+			r.addSimpleToken(MINUS_MINUS)
+		} else {
+			r.addSimpleToken(r.matchReturn("=", MINUS_EQUAL, MINUS))
+		}
 		break
 	case '+':
-		r.addSimpleToken(PLUS)
+		if r.match("+") {
+			r.addSimpleToken(PLUS_PLUS)
+		} else {
+			r.addSimpleToken(r.matchReturn("=", PLUS_EQUAL, PLUS))
+		}
 		break
 	case ';':
 		r.addSimpleToken(SEMICOLON)
 		break
 	case '*':
-		r.addSimpleToken(STAR)
+		if r.match("*") {
+			r.addSimpleToken(STAR_STAR)
+		} else {
+			r.addSimpleToken(r.matchReturn("=", STAR_EQUAL, STAR))
+		}
+		break
+	case '~':
+		r.addSimpleToken(r.matchReturn("/", TILDE_SLASH, TILDE))
+		break
+	case '%':
+		r.addSimpleToken(r.matchReturn("=", PERCENT_EQUAL, PERCENT))
+		break
+	case '&':
+		r.addSimpleToken(AMPERSAND)
+		break
+	case '|':
+		r.addSimpleToken(PIPE)
+		break
+	case '^':
+		r.addSimpleToken(CARET)
+		break
+	case '?':
+		r.addSimpleToken(r.matchReturn("?", QUESTION_QUESTION, QUESTION))
+		break
+	case ':':
+		r.addSimpleToken(COLON)
 		break
 		// multi-token operators
 	case '!':
 		r.addSimpleToken(r.matchReturn("=", BANG_EQUAL, BANG))
 		break
 	case '=':
-		r.addSimpleToken(r.matchReturn("=", EQUAL_EQUAL, EQUAL))
+		if r.match(">") {
+			r.addSimpleToken(ARROW)
+		} else {
+			r.addSimpleToken(r.matchReturn("=", EQUAL_EQUAL, EQUAL))
+		}
 		break
 	case '<':
-		r.addSimpleToken(r.matchReturn("=", LESS_EQUAL, LESS))
+		if r.match("<") {
+			r.addSimpleToken(LESS_LESS)
+		} else {
+			r.addSimpleToken(r.matchReturn("=", LESS_EQUAL, LESS))
+		}
 		break
 	case '>':
-		r.addSimpleToken(r.matchReturn("=", GREATER_EQUAL, GREATER))
+		if r.match(">") {
+			r.addSimpleToken(GREATER_GREATER)
+		} else {
+			r.addSimpleToken(r.matchReturn("=", GREATER_EQUAL, GREATER))
+		}
 		break
 	case '/':
 		// single line comment
@@ -125,8 +237,12 @@ func (r *Lexer) scanToken() error {
 			for r.peek() != "\n" && !r.isAtEnd() {
 				r.advance()
 			}
+			r.comments = append(r.comments, Comment{
+				Text: r.Source[r.start:r.current],
+				Line: r.line,
+			})
 		} else {
-			r.addSimpleToken(SLASH)
+			r.addSimpleToken(r.matchReturn("=", SLASH_EQUAL, SLASH))
 		}
 
 	case ' ':
@@ -136,14 +252,10 @@ func (r *Lexer) scanToken() error {
 		break
 	case '\n':
 		r.line++
+		r.lineStart = r.current
 		break
 	case '"':
-		stringLiteral, syntaxError := r.string()
-		if syntaxError != nil {
-			return syntaxError
-		}
-		r.addToken(STRING, stringLiteral)
-		break
+		return r.stringLiteral()
 	default:
 		if unicode.IsDigit(rune(c)) {
 			numberLiteral, err := r.number()
@@ -151,7 +263,7 @@ func (r *Lexer) scanToken() error {
 				return err
 			}
 			r.addToken(NUMBER, numberLiteral)
-		} else if unicode.IsLetter(rune(c)) {
+		} else if unicode.IsLetter(rune(c)) || c == '_' {
 			r.addSimpleToken(r.identifier())
 		} else {
 			return UnexpectedCharacter
@@ -160,8 +272,23 @@ func (r *Lexer) scanToken() error {
 	return nil
 }
 
+// Comments returns comments found by ScanTokens, which are not part of the token stream
+func (r *Lexer) Comments() []Comment {
+	return r.comments
+}
+
+// skipShebang ignores interpreter directive on the first line of executable scripts
+func (r *Lexer) skipShebang() {
+	if !strings.HasPrefix(r.Source, "#!") {
+		return
+	}
+	for r.peek() != "\n" && !r.isAtEnd() {
+		r.advance()
+	}
+}
+
 func (r *Lexer) identifier() TokenType {
-	for unicode.IsLetter(r.peekAsRune()) || unicode.IsDigit(r.peekAsRune()) {
+	for unicode.IsLetter(r.peekAsRune()) || unicode.IsDigit(r.peekAsRune()) || r.peekAsRune() == '_' {
 		r.advance()
 	}
 	literal := r.Source[r.start:r.current]
@@ -173,40 +300,194 @@ func (r *Lexer) identifier() TokenType {
 	}
 }
 
-func (r *Lexer) number() (float64, error) {
-	for unicode.IsDigit(r.peekAsRune()) {
+// number scans number literal. Integers are int64, or *big.Int with suffix n,
+// and may be written in hexadecimal, binary or octal with prefix 0x, 0b or 0o.
+// Literals with fraction or exponent are float64, decimal.Decimal with suffix d.
+// Digits may be separated by underscores, e.g. 1_000_000.
+func (r *Lexer) number() (any, error) {
+	r.current = r.start
+	if r.peek() == "0" {
+		if base, ok := radixPrefixes[strings.ToLower(r.peekNext())]; ok {
+			r.current += 2
+			return r.integer(base)
+		}
+	}
+
+	text, err := r.digits(10)
+	if err != nil {
+		return nil, err
+	}
+	fraction, exponent := false, false
+	if r.peek() == "." && isDigit(r.peekNext()[0], 10) {
 		r.advance()
+		digits, err := r.digits(10)
+		if err != nil {
+			return nil, err
+		}
+		text += "." + digits
+		fraction = true
 	}
-	if r.peek() == "." && unicode.IsDigit(rune(r.peekNext()[0])) {
+	if r.peek() == "e" || r.peek() == "E" {
 		r.advance()
-		for unicode.IsDigit(r.peekAsRune()) {
+		sign := ""
+		if r.peek() == "+" || r.peek() == "-" {
+			sign = string(r.advance())
+		}
+		digits, err := r.digits(10)
+		if err != nil {
+			return nil, err
+		}
+		text += "e" + sign + digits
+		exponent = true
+	}
+
+	suffix := r.current
+	switch {
+	case r.match("d"):
+		if exponent {
+			return nil, r.invalidNumber(suffix, "decimal literal can't have exponent")
+		}
+		d, err := decimal.Parse(text)
+		if err != nil {
+			return nil, r.invalidNumber(r.start, err.Error())
+		}
+		return d, r.checkNumberEnd(10)
+	case r.match("n"):
+		if fraction || exponent {
+			return nil, r.invalidNumber(suffix, "big integer literal can't have fraction or exponent")
+		}
+		i, _ := new(big.Int).SetString(text, 10)
+		return i, r.checkNumberEnd(10)
+	case fraction || exponent:
+		float, err := strconv.ParseFloat(text, 64)
+		if err != nil {
+			return nil, r.invalidNumber(r.start, "number literal out of range")
+		}
+		return float, r.checkNumberEnd(10)
+	}
+	integer, err := strconv.ParseInt(text, 10, 64)
+	if err != nil {
+		return nil, r.invalidNumber(r.start, "integer literal out of range, use suffix n for big integer")
+	}
+	return integer, r.checkNumberEnd(10)
+}
+
+var radixPrefixes = map[string]int{"x": 16, "b": 2, "o": 8}
+
+var radixNames = map[int]string{16: "hexadecimal", 10: "decimal", 8: "octal", 2: "binary"}
+
+// integer scans digits of integer in given base following its prefix
+func (r *Lexer) integer(base int) (any, error) {
+	text, err := r.digits(base)
+	if err != nil {
+		return nil, err
+	}
+	if r.match("n") {
+		i, _ := new(big.Int).SetString(text, base)
+		return i, r.checkNumberEnd(base)
+	}
+	integer, err := strconv.ParseInt(text, base, 64)
+	if err != nil {
+		return nil, r.invalidNumber(r.start, "integer literal out of range, use suffix n for big integer")
+	}
+	return integer, r.checkNumberEnd(base)
+}
+
+// digits scans at least one digit of given base, digits may be separated by
+// single underscores which are left out of the result
+func (r *Lexer) digits(base int) (string, error) {
+	var sb strings.Builder
+	for {
+		c := r.peek()[0]
+		if isDigit(c, base) {
+			sb.WriteByte(r.advance())
+			continue
+		}
+		if c == '_' && sb.Len() > 0 && isDigit(r.peekNext()[0], base) {
 			r.advance()
+			continue
+		}
+		if c == '_' {
+			return "", r.invalidNumber(r.current, "'_' must separate digits")
 		}
+		break
 	}
-	literal := r.Source[r.start:r.current]
-	float, err := strconv.ParseFloat(literal, 6)
+	if sb.Len() == 0 {
+		return "", r.invalidNumber(r.current, fmt.Sprintf("expected %s digit", radixNames[base]))
+	}
+	return sb.String(), nil
+}
+
+// checkNumberEnd fails if number literal is immediately followed by letter or
+// digit, e.g. in 0b102 or 1nd
+func (r *Lexer) checkNumberEnd(base int) error {
+	if c := r.peekAsRune(); unicode.IsLetter(c) || unicode.IsDigit(c) || c == '_' {
+		return r.invalidNumber(r.current, fmt.Sprintf("invalid character %q in %s literal", c, radixNames[base]))
+	}
+	return nil
+}
+
+// invalidNumber returns InvalidNumber error caused by character at position
+func (r *Lexer) invalidNumber(position int, message string) error {
+	r.errorAt = position
+	return fmt.Errorf("%w: %s", InvalidNumber, message)
+}
+
+func isDigit(c byte, base int) bool {
+	switch {
+	case c >= '0' && c <= '9':
+		return int(c-'0') < base
+	case c >= 'a' && c <= 'f', c >= 'A' && c <= 'F':
+		return base == 16
+	}
+	return false
+}
+
+// stringLiteral adds token of string literal or of its part following
+// interpolated expression, the opening " or } is already consumed
+func (r *Lexer) stringLiteral() error {
+	stringLiteral, tokenType, err := r.string()
 	if err != nil {
-		return 0.0, errors.Join(InvalidNumber, err)
+		return err
 	}
-	return float, nil
+	r.addToken(tokenType, stringLiteral)
+	return nil
 }
 
-func (r *Lexer) string() (string, error) {
+// string scans string up to the closing " or up to "${" starting interpolated
+// expression, in which case the part is of type INTERPOLATION
+func (r *Lexer) string() (string, TokenType, error) {
 	for r.peek() != "\"" && !r.isAtEnd() {
-		if r.peek() == "\n" {
+		if r.peek() == "\\" && r.peekNext() == "$" {
+			// escaped $ doesn't start interpolated expression
+			r.current += 2
+			continue
+		}
+		if r.peek() == "$" && r.peekNext() == "{" {
+			text := unescape(r.Source[r.start+1 : r.current])
+			r.current += 2
+			r.interpolations = append(r.interpolations, 0)
+			return text, INTERPOLATION, nil
+		}
+		if r.advance() == '\n' {
 			r.line++
+			r.lineStart = r.current
 		}
-		r.advance()
 	}
 
 	if r.isAtEnd() {
-		return "", UnterminatedString
+		return "", STRING, UnterminatedString
 	}
 
 	// scan tle closing "
 	r.advance()
 
-	return r.clean(r.Source[r.start+1 : r.current-1]), nil
+	return unescape(r.clean(r.Source[r.start+1 : r.current-1])), STRING, nil
+}
+
+// unescape replaces \$ in string literal by $
+func unescape(s string) string {
+	return strings.ReplaceAll(s, "\\$", "$")
 }
 
 func (r *Lexer) advance() byte {
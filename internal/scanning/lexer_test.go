@@ -0,0 +1,63 @@
+package scanning
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestReportsColumnOfInvalidCharacterInNumber(t *testing.T) {
+	tests := []struct {
+		source string
+		column int
+	}{
+		{"0b1021", 5},
+		{"var a = 0xZ;", 11},
+		{"1_000_", 6},
+		{"1.5e", 5},
+		{"12abc", 3},
+		{"print 1;\n  1.5n;", 6},
+		{"99999999999999999999", 1},
+	}
+	for _, test := range tests {
+		_, err := NewLexer(test.source).ScanTokens()
+		if err == nil {
+			t.Errorf("%q: expected error", test.source)
+			continue
+		}
+		if !errors.Is(err.error, InvalidNumber) {
+			t.Errorf("%q: expected invalid number, got %v", test.source, err)
+		}
+		if err.Column != test.column {
+			t.Errorf("%q: expected column %d, got %d", test.source, test.column, err.Column)
+		}
+	}
+}
+
+func TestScansRangesAndIdentifiers(t *testing.T) {
+	tests := []struct {
+		source string
+		types  []TokenType
+	}{
+		{"1..5", []TokenType{NUMBER, DOT_DOT, NUMBER, EOF}},
+		{"1..=5", []TokenType{NUMBER, DOT_DOT_EQUAL, NUMBER, EOF}},
+		{"1.5..2", []TokenType{NUMBER, DOT_DOT, NUMBER, EOF}},
+		{"a...", []TokenType{IDENTIFIER, ELLIPSIS, EOF}},
+		{"_ my_name", []TokenType{IDENTIFIER, IDENTIFIER, EOF}},
+	}
+	for _, test := range tests {
+		tokens, err := NewLexer(test.source).ScanTokens()
+		if err != nil {
+			t.Errorf("%q: unexpected error %v", test.source, err)
+			continue
+		}
+		if len(tokens) != len(test.types) {
+			t.Errorf("%q: expected %d tokens, got %d", test.source, len(test.types), len(tokens))
+			continue
+		}
+		for i, token := range tokens {
+			if token.TokenType != test.types[i] {
+				t.Errorf("%q: expected %s at %d, got %s", test.source, test.types[i], i, token.TokenType)
+			}
+		}
+	}
+}
@@ -13,44 +13,76 @@ const (
 	RIGHT_BRACE
 	COMMA
 	DOT
+	ELLIPSIS
+	DOT_DOT
+	DOT_DOT_EQUAL
 	MINUS
 	PLUS
 	SEMICOLON
 	SLASH
 	STAR
+	COLON
+	PERCENT
+	AMPERSAND
+	PIPE
+	CARET
+	QUESTION
 
 	// One or two character tokens.
 	BANG
 	BANG_EQUAL
 	EQUAL
 	EQUAL_EQUAL
+	ARROW
 	GREATER
 	GREATER_EQUAL
 	LESS
 	LESS_EQUAL
+	STAR_STAR
+	TILDE
+	TILDE_SLASH // integer division, // can't be used as it starts a comment
+	LESS_LESS
+	GREATER_GREATER
+	PLUS_EQUAL
+	MINUS_EQUAL
+	STAR_EQUAL
+	SLASH_EQUAL
+	PERCENT_EQUAL
+	PLUS_PLUS
+	MINUS_MINUS
+	QUESTION_QUESTION
 
 	// Literals.
 	IDENTIFIER
 	STRING
+	INTERPOLATION // part of string literal followed by interpolated expression
 	NUMBER
 
 	// Keywords.
 	AND
+	BREAK
+	CASE
 	CLASS
+	CONTINUE
 	ELSE
 	FALSE
 	FUN
 	FOR
 	IF
+	IN
+	MATCH
 	NIL
 	OR
 	PRINT
 	RETURN
+	SELECT
+	SPAWN
 	SUPER
 	THIS
 	TRUE
 	VAR
 	WHILE
+	YIELD
 
 	EOF
 )
@@ -14,44 +14,76 @@ func _() {
 	_ = x[RIGHT_BRACE-3]
 	_ = x[COMMA-4]
 	_ = x[DOT-5]
-	_ = x[MINUS-6]
-	_ = x[PLUS-7]
-	_ = x[SEMICOLON-8]
-	_ = x[SLASH-9]
-	_ = x[STAR-10]
-	_ = x[BANG-11]
-	_ = x[BANG_EQUAL-12]
-	_ = x[EQUAL-13]
-	_ = x[EQUAL_EQUAL-14]
-	_ = x[GREATER-15]
-	_ = x[GREATER_EQUAL-16]
-	_ = x[LESS-17]
-	_ = x[LESS_EQUAL-18]
-	_ = x[IDENTIFIER-19]
-	_ = x[STRING-20]
-	_ = x[NUMBER-21]
-	_ = x[AND-22]
-	_ = x[CLASS-23]
-	_ = x[ELSE-24]
-	_ = x[FALSE-25]
-	_ = x[FUN-26]
-	_ = x[FOR-27]
-	_ = x[IF-28]
-	_ = x[NIL-29]
-	_ = x[OR-30]
-	_ = x[PRINT-31]
-	_ = x[RETURN-32]
-	_ = x[SUPER-33]
-	_ = x[THIS-34]
-	_ = x[TRUE-35]
-	_ = x[VAR-36]
-	_ = x[WHILE-37]
-	_ = x[EOF-38]
+	_ = x[ELLIPSIS-6]
+	_ = x[DOT_DOT-7]
+	_ = x[DOT_DOT_EQUAL-8]
+	_ = x[MINUS-9]
+	_ = x[PLUS-10]
+	_ = x[SEMICOLON-11]
+	_ = x[SLASH-12]
+	_ = x[STAR-13]
+	_ = x[COLON-14]
+	_ = x[PERCENT-15]
+	_ = x[AMPERSAND-16]
+	_ = x[PIPE-17]
+	_ = x[CARET-18]
+	_ = x[QUESTION-19]
+	_ = x[BANG-20]
+	_ = x[BANG_EQUAL-21]
+	_ = x[EQUAL-22]
+	_ = x[EQUAL_EQUAL-23]
+	_ = x[ARROW-24]
+	_ = x[GREATER-25]
+	_ = x[GREATER_EQUAL-26]
+	_ = x[LESS-27]
+	_ = x[LESS_EQUAL-28]
+	_ = x[STAR_STAR-29]
+	_ = x[TILDE-30]
+	_ = x[TILDE_SLASH-31]
+	_ = x[LESS_LESS-32]
+	_ = x[GREATER_GREATER-33]
+	_ = x[PLUS_EQUAL-34]
+	_ = x[MINUS_EQUAL-35]
+	_ = x[STAR_EQUAL-36]
+	_ = x[SLASH_EQUAL-37]
+	_ = x[PERCENT_EQUAL-38]
+	_ = x[PLUS_PLUS-39]
+	_ = x[MINUS_MINUS-40]
+	_ = x[QUESTION_QUESTION-41]
+	_ = x[IDENTIFIER-42]
+	_ = x[STRING-43]
+	_ = x[INTERPOLATION-44]
+	_ = x[NUMBER-45]
+	_ = x[AND-46]
+	_ = x[BREAK-47]
+	_ = x[CASE-48]
+	_ = x[CLASS-49]
+	_ = x[CONTINUE-50]
+	_ = x[ELSE-51]
+	_ = x[FALSE-52]
+	_ = x[FUN-53]
+	_ = x[FOR-54]
+	_ = x[IF-55]
+	_ = x[IN-56]
+	_ = x[MATCH-57]
+	_ = x[NIL-58]
+	_ = x[OR-59]
+	_ = x[PRINT-60]
+	_ = x[RETURN-61]
+	_ = x[SELECT-62]
+	_ = x[SPAWN-63]
+	_ = x[SUPER-64]
+	_ = x[THIS-65]
+	_ = x[TRUE-66]
+	_ = x[VAR-67]
+	_ = x[WHILE-68]
+	_ = x[YIELD-69]
+	_ = x[EOF-70]
 }
 
-const _TokenType_name = "LEFT_PARENRIGHT_PARENLEFT_BRACERIGHT_BRACECOMMADOTMINUSPLUSSEMICOLONSLASHSTARBANGBANG_EQUALEQUALEQUAL_EQUALGREATERGREATER_EQUALLESSLESS_EQUALIDENTIFIERSTRINGNUMBERANDCLASSELSEFALSEFUNFORIFNILORPRINTRETURNSUPERTHISTRUEVARWHILEEOF"
+const _TokenType_name = "LEFT_PARENRIGHT_PARENLEFT_BRACERIGHT_BRACECOMMADOTELLIPSISDOT_DOTDOT_DOT_EQUALMINUSPLUSSEMICOLONSLASHSTARCOLONPERCENTAMPERSANDPIPECARETQUESTIONBANGBANG_EQUALEQUALEQUAL_EQUALARROWGREATERGREATER_EQUALLESSLESS_EQUALSTAR_STARTILDETILDE_SLASHLESS_LESSGREATER_GREATERPLUS_EQUALMINUS_EQUALSTAR_EQUALSLASH_EQUALPERCENT_EQUALPLUS_PLUSMINUS_MINUSQUESTION_QUESTIONIDENTIFIERSTRINGINTERPOLATIONNUMBERANDBREAKCASECLASSCONTINUEELSEFALSEFUNFORIFINMATCHNILORPRINTRETURNSELECTSPAWNSUPERTHISTRUEVARWHILEYIELDEOF"
 
-var _TokenType_index = [...]uint8{0, 10, 21, 31, 42, 47, 50, 55, 59, 68, 73, 77, 81, 91, 96, 107, 114, 127, 131, 141, 151, 157, 163, 166, 171, 175, 180, 183, 186, 188, 191, 193, 198, 204, 209, 213, 217, 220, 225, 228}
+var _TokenType_index = [...]uint16{0, 10, 21, 31, 42, 47, 50, 58, 65, 78, 83, 87, 96, 101, 105, 110, 117, 126, 130, 135, 143, 147, 157, 162, 173, 178, 185, 198, 202, 212, 221, 226, 237, 246, 261, 271, 282, 292, 303, 316, 325, 336, 353, 363, 369, 382, 388, 391, 396, 400, 405, 413, 417, 422, 425, 428, 430, 432, 437, 440, 442, 447, 453, 459, 464, 469, 473, 477, 480, 485, 490, 493}
 
 func (i TokenType) String() string {
 	if i < 0 || i >= TokenType(len(_TokenType_index)-1) {
@@ -0,0 +1,602 @@
+package typing
+
+import (
+	"errors"
+	"fmt"
+	"gox/internal"
+	"gox/internal/ast"
+	"gox/internal/decimal"
+	"gox/internal/runtime"
+	"gox/internal/scanning"
+	"math/big"
+)
+
+var (
+	undefinedVariable = errors.New("undefined variable")
+)
+
+// nativeSignature holds types of parameters and result of function provided by the runtime
+type nativeSignature struct {
+	params []Type // parameters missing here are of type Any
+	result Type   // Any if nil
+}
+
+// natives holds types of functions provided by the runtime, their arity and names
+// of parameters are taken from the functions themselves
+var natives = map[string]nativeSignature{
+	"clock":   {result: Number},
+	"args":    {result: List},
+	"len":     {result: Number},
+	"get":     {params: []Type{List, Number}},
+	"list":    {result: List},
+	"done":    {result: Bool},
+	"channel": {params: []Type{Number}},
+	"send":    {result: Nil},
+	"close":   {result: Nil},
+}
+
+type TypeError struct {
+	error
+	Token *scanning.Token
+}
+
+// Checker verifies that values flowing through the program match optional type
+// annotations. Unannotated variables, parameters and return values are of type Any
+// and are never reported, so that code without annotations always passes.
+type Checker struct {
+	scopes     []map[string]Type
+	signatures map[*ast.Function]*FunctionType
+	returnType Type // declared return type of enclosing function, nil on top level
+	errors     []*TypeError
+}
+
+// NewChecker creates checker aware of given global names, e.g. natives of the
+// interpreter the program is going to run in
+func NewChecker(globals []string) *Checker {
+	std := make(map[string]runtime.LoxStdFunction, len(runtime.StdFunctions))
+	for _, fn := range runtime.StdFunctions {
+		std[fn.Name()] = fn
+	}
+	global := make(map[string]Type)
+	for _, name := range globals {
+		if fn, ok := std[name]; ok {
+			global[name] = nativeType(fn)
+		} else {
+			global[name] = Any
+		}
+	}
+	return &Checker{
+		scopes:     []map[string]Type{global},
+		signatures: make(map[*ast.Function]*FunctionType),
+	}
+}
+
+// nativeType returns signature of function provided by the runtime
+func nativeType(fn runtime.LoxStdFunction) *FunctionType {
+	signature := natives[fn.Name()]
+	res := &FunctionType{Return: Any}
+	if signature.result != nil {
+		res.Return = signature.result
+	}
+	minArgs, maxArgs := fn.Arity()
+	if maxArgs == runtime.Variadic {
+		maxArgs = minArgs
+		res.Rest = Any
+	}
+	res.Params = make([]Type, maxArgs)
+	for i := range res.Params {
+		res.Params[i] = Any
+		if i < len(signature.params) {
+			res.Params[i] = signature.params[i]
+		}
+	}
+	res.Optional = maxArgs - minArgs
+	if named, ok := fn.(runtime.NamedParams); ok {
+		res.Names = named.ParamNames()
+	}
+	return res
+}
+
+// Check returns all type errors found in statements
+func (r *Checker) Check(statements []*ast.Stmt) []*TypeError {
+	res := make([]ast.Stmt, 0, len(statements))
+	for _, stmt := range statements {
+		if stmt != nil {
+			res = append(res, *stmt)
+		}
+	}
+	r.checkStatements(res)
+	return r.errors
+}
+
+// expressions
+func (r *Checker) VisitForLiteral(expr *ast.Literal) (any, *internal.RuntimeError) {
+	switch expr.Value.(type) {
+	case int64, *big.Int, decimal.Decimal, float64:
+		return Number, nil
+	case string:
+		return String, nil
+	case bool:
+		return Bool, nil
+	case nil:
+		return Nil, nil
+	}
+	return Any, nil
+}
+
+func (r *Checker) VisitForUnary(expr *ast.Unary) (any, *internal.RuntimeError) {
+	right := r.typeOf(*expr.Right)
+	switch expr.Operator.TokenType {
+	case scanning.BANG:
+		return Bool, nil
+	case scanning.MINUS, scanning.TILDE:
+		if !assignable(Number, right) {
+			r.report(expr.Operator, "operand must be number, got %s", right)
+		}
+		return Number, nil
+	}
+	return Any, nil
+}
+
+func (r *Checker) VisitForBinary(expr *ast.Binary) (any, *internal.RuntimeError) {
+	return r.binaryType(expr.Operator, r.typeOf(*expr.Left), r.typeOf(*expr.Right)), nil
+}
+
+// binaryType returns type of binary operation on operands of given types
+func (r *Checker) binaryType(operator *scanning.Token, left, right Type) Type {
+	switch operator.TokenType {
+	case scanning.EQUAL_EQUAL, scanning.BANG_EQUAL:
+		if !r.sameNumbersOrStrings(left, right) {
+			r.report(operator, "operands must be two numbers or two strings, got %s and %s", left, right)
+		}
+		return Bool
+	case scanning.GREATER, scanning.GREATER_EQUAL, scanning.LESS, scanning.LESS_EQUAL:
+		r.checkNumberOperands(operator, left, right)
+		return Bool
+	case scanning.PLUS:
+		if !r.sameNumbersOrStrings(left, right) {
+			r.report(operator, "operands must be two numbers or two strings, got %s and %s", left, right)
+			return Any
+		}
+		return join(left, right)
+	case scanning.MINUS, scanning.SLASH, scanning.STAR, scanning.TILDE_SLASH, scanning.PERCENT, scanning.STAR_STAR,
+		scanning.AMPERSAND, scanning.PIPE, scanning.CARET, scanning.LESS_LESS, scanning.GREATER_GREATER:
+		r.checkNumberOperands(operator, left, right)
+		return Number
+	case scanning.DOT_DOT, scanning.DOT_DOT_EQUAL:
+		r.checkNumberOperands(operator, left, right)
+		return Range
+	}
+	return Any
+}
+
+func (r *Checker) VisitForGrouping(expr *ast.Grouping) (any, *internal.RuntimeError) {
+	return r.typeOf(*expr.Expression), nil
+}
+
+func (r *Checker) VisitForVariableExpression(expr *ast.VarExpr) (any, *internal.RuntimeError) {
+	return r.lookup(expr.Name), nil
+}
+
+func (r *Checker) VisitForAssignExpression(expr *ast.Assign) (any, *internal.RuntimeError) {
+	target := r.lookup(expr.Name)
+	value := r.typeOf(expr.Value)
+	if expr.Operator != nil {
+		value = r.binaryType(expr.Operator, target, value)
+	}
+	if !assignable(target, value) {
+		r.report(expr.Name, "cannot assign %s to variable of type %s", value, target)
+	}
+	return value, nil
+}
+
+func (r *Checker) VisitForLogical(expr *ast.Logical) (any, *internal.RuntimeError) {
+	return join(r.typeOf(expr.Left), r.typeOf(expr.Right)), nil
+}
+
+func (r *Checker) VisitForConditional(expr *ast.Conditional) (any, *internal.RuntimeError) {
+	r.typeOf(expr.Condition)
+	return join(r.typeOf(expr.Then), r.typeOf(expr.Else)), nil
+}
+
+func (r *Checker) VisitForFunctionCall(call *ast.Call) (any, *internal.RuntimeError) {
+	callee := r.typeOf(call.Callee)
+	args := make([]Type, len(call.Params))
+	for i, param := range call.Params {
+		args[i] = r.typeOf(param)
+	}
+
+	if !isCallable(callee) {
+		r.report(call.Paren, "cannot call value of type %s", callee)
+		r.typeOfNamed(call.Named)
+		return Any, nil
+	}
+	function, ok := callee.(*FunctionType)
+	if !ok {
+		r.typeOfNamed(call.Named)
+		return Any, nil
+	}
+	if len(call.Named) > 0 {
+		if args, ok = r.bindNamed(call, function, args); !ok {
+			return function.Return, nil
+		}
+	}
+	required := len(function.Params) - function.Optional
+	if len(args) < required || function.Rest == nil && len(args) > len(function.Params) {
+		var expected string
+		switch {
+		case function.Rest != nil:
+			expected = fmt.Sprintf("at least %d", required)
+		case function.Optional > 0:
+			expected = fmt.Sprintf("%d to %d", required, len(function.Params))
+		default:
+			expected = fmt.Sprint(required)
+		}
+		noun := "arguments"
+		if required == 1 && (function.Rest != nil || function.Optional == 0) {
+			noun = "argument"
+		}
+		r.report(call.Paren, "expected %s %s but got %d", expected, noun, len(args))
+		return function.Return, nil
+	}
+	for i, arg := range args {
+		expected := function.Rest
+		if i < len(function.Params) {
+			expected = function.Params[i]
+		}
+		if arg != nil && !assignable(expected, arg) {
+			r.report(call.Paren, "argument %d: expected %s but got %s", i+1, expected, arg)
+		}
+	}
+	return function.Return, nil
+}
+
+// bindNamed places types of arguments passed by name at positions of parameters
+// of the same name, positions of omitted parameters are nil
+func (r *Checker) bindNamed(call *ast.Call, function *FunctionType, args []Type) ([]Type, bool) {
+	named := r.typeOfNamed(call.Named)
+	if function.Names == nil {
+		r.report(call.Paren, "function doesn't accept named arguments")
+		return nil, false
+	}
+	for i, arg := range call.Named {
+		index := -1
+		for j, name := range function.Names {
+			if name == arg.Name.Lexeme {
+				index = j
+			}
+		}
+		if index < 0 {
+			r.report(arg.Name, "unknown parameter %s", arg.Name.Lexeme)
+			return nil, false
+		}
+		if index < len(args) && args[index] != nil {
+			r.report(arg.Name, "parameter %s is passed more than once", arg.Name.Lexeme)
+			return nil, false
+		}
+		for len(args) <= index {
+			args = append(args, nil)
+		}
+		args[index] = named[i]
+	}
+	for i := 0; i < len(function.Params)-function.Optional && i < len(args); i++ {
+		if args[i] == nil {
+			r.report(call.Paren, "missing argument for parameter %s", function.Names[i])
+			return nil, false
+		}
+	}
+	return args, true
+}
+
+func (r *Checker) typeOfNamed(args []*ast.NamedArg) []Type {
+	res := make([]Type, len(args))
+	for i, arg := range args {
+		res[i] = r.typeOf(arg.Value)
+	}
+	return res
+}
+
+func (r *Checker) VisitForSpawn(expr *ast.Spawn) (any, *internal.RuntimeError) {
+	r.typeOf(expr.Call)
+	return Any, nil
+}
+
+func (r *Checker) VisitForInterpolation(expr *ast.Interpolation) (any, *internal.RuntimeError) {
+	for _, part := range expr.Parts {
+		r.typeOf(part)
+	}
+	return String, nil
+}
+
+func (r *Checker) VisitForLambda(lambda *ast.Lambda) (any, *internal.RuntimeError) {
+	signature := r.signature(lambda.Params, lambda.ReturnType)
+	r.checkBody(signature, lambda.Params, lambda.Body)
+	return signature, nil
+}
+
+// statements
+func (r *Checker) VisitForExpression(stmt *ast.Expression) (internal.Completion, *internal.RuntimeError) {
+	r.typeOf(*stmt.Expression)
+	return internal.NormalCompletion, nil
+}
+
+func (r *Checker) VisitForPrint(stmt *ast.Print) (internal.Completion, *internal.RuntimeError) {
+	r.typeOf(*stmt.Expression)
+	return internal.NormalCompletion, nil
+}
+
+func (r *Checker) VisitForVar(stmt *ast.Var) (internal.Completion, *internal.RuntimeError) {
+	declared := r.resolveType(stmt.Type)
+	if stmt.Initializer == nil {
+		r.declare(stmt.Name.Lexeme, r.orAny(declared))
+		return internal.NormalCompletion, nil
+	}
+
+	// the variable may be referenced by function it's initialized with
+	r.declare(stmt.Name.Lexeme, r.orAny(declared))
+	value := r.typeOf(*stmt.Initializer)
+	if declared != nil && !assignable(declared, value) {
+		r.report(stmt.Name, "expected %s but got %s", declared, value)
+	}
+	return internal.NormalCompletion, nil
+}
+
+func (r *Checker) VisitForBlock(block *ast.Block) (internal.Completion, *internal.RuntimeError) {
+	r.beginScope()
+	defer r.endScope()
+	r.checkStatements(block.Statements)
+	return internal.NormalCompletion, nil
+}
+
+func (r *Checker) VisitForIf(ifStmt *ast.If) (internal.Completion, *internal.RuntimeError) {
+	r.typeOf(ifStmt.Condition)
+	r.check(ifStmt.Then)
+	if ifStmt.Else != nil {
+		r.check(ifStmt.Else)
+	}
+	return internal.NormalCompletion, nil
+}
+
+func (r *Checker) VisitForMatch(stmt *ast.Match) (internal.Completion, *internal.RuntimeError) {
+	value := r.typeOf(stmt.Value)
+	for _, c := range stmt.Cases {
+		r.beginScope()
+		for _, pattern := range c.Patterns {
+			r.typeOf(pattern.Value)
+			r.typeOf(pattern.To)
+			if pattern.Binding != nil && pattern.Binding.Lexeme != "_" {
+				r.declare(pattern.Binding.Lexeme, value)
+			}
+		}
+		r.typeOf(c.Guard)
+		r.check(c.Body)
+		r.endScope()
+	}
+	return internal.NormalCompletion, nil
+}
+
+func (r *Checker) VisitForForIn(stmt *ast.ForIn) (internal.Completion, *internal.RuntimeError) {
+	element := Type(Any)
+	switch r.typeOf(stmt.Iterable) {
+	case Range:
+		element = Number
+	case String:
+		element = String
+	}
+	r.beginScope()
+	r.declare(stmt.Name.Lexeme, element)
+	r.check(stmt.Body)
+	r.endScope()
+	return internal.NormalCompletion, nil
+}
+
+func (r *Checker) VisitForWhile(while *ast.While) (internal.Completion, *internal.RuntimeError) {
+	r.typeOf(while.Condition)
+	r.check(while.Statement)
+	r.typeOf(while.Increment)
+	return internal.NormalCompletion, nil
+}
+
+func (r *Checker) VisitForFunction(function *ast.Function) (internal.Completion, *internal.RuntimeError) {
+	signature := r.functionType(function)
+	r.declare(function.Name.Lexeme, signature)
+	r.checkBody(signature, function.Params, function.Body)
+	return internal.NormalCompletion, nil
+}
+
+func (r *Checker) VisitForReturn(ret *ast.Return) (internal.Completion, *internal.RuntimeError) {
+	var value Type = Nil
+	if ret.Value != nil {
+		value = r.typeOf(ret.Value)
+	}
+	if r.returnType != nil && !assignable(r.returnType, value) {
+		r.report(ret.Name, "function must return %s but returns %s", r.returnType, value)
+	}
+	return internal.NormalCompletion, nil
+}
+
+func (r *Checker) VisitForSelect(stmt *ast.Select) (internal.Completion, *internal.RuntimeError) {
+	for _, c := range stmt.Cases {
+		r.typeOf(c.Channel)
+		r.typeOf(c.Value)
+		r.beginScope()
+		if c.Binding != nil {
+			r.declare(c.Binding.Lexeme, Any)
+		}
+		r.check(c.Body)
+		r.endScope()
+	}
+	return internal.NormalCompletion, nil
+}
+
+func (r *Checker) VisitForYield(stmt *ast.Yield) (internal.Completion, *internal.RuntimeError) {
+	r.typeOf(stmt.Value)
+	return internal.NormalCompletion, nil
+}
+
+func (r *Checker) VisitForBreak(stmt *ast.Break) (internal.Completion, *internal.RuntimeError) {
+	return internal.NormalCompletion, nil
+}
+
+func (r *Checker) VisitForContinue(stmt *ast.Continue) (internal.Completion, *internal.RuntimeError) {
+	return internal.NormalCompletion, nil
+}
+
+func (r *Checker) check(stmt ast.Stmt) {
+	if stmt == nil {
+		return
+	}
+	_, _ = stmt.Accept(r)
+}
+
+// checkStatements checks statements of a scope. Functions and variables may be
+// referenced from bodies of functions declared before them, so they are declared first.
+func (r *Checker) checkStatements(statements []ast.Stmt) {
+	for _, stmt := range statements {
+		switch declaration := stmt.(type) {
+		case *ast.Function:
+			r.declare(declaration.Name.Lexeme, r.functionType(declaration))
+		case *ast.Var:
+			r.declare(declaration.Name.Lexeme, Any)
+		}
+	}
+	for _, stmt := range statements {
+		r.check(stmt)
+	}
+}
+
+func (r *Checker) typeOf(expr ast.Expr) Type {
+	if expr == nil {
+		return Any
+	}
+	t, _ := expr.Accept(r)
+	return t.(Type)
+}
+
+func (r *Checker) functionType(function *ast.Function) *FunctionType {
+	if signature, ok := r.signatures[function]; ok {
+		return signature
+	}
+	signature := r.signature(function.Params, function.ReturnType)
+	r.signatures[function] = signature
+	return signature
+}
+
+func (r *Checker) signature(params []*ast.Param, returnType *scanning.Token) *FunctionType {
+	signature := &FunctionType{
+		Params: make([]Type, 0, len(params)),
+		Names:  make([]string, 0, len(params)),
+		Return: r.resolveTypeOrAny(returnType),
+	}
+	for _, param := range params {
+		paramType := r.resolveTypeOrAny(param.Type)
+		switch {
+		case param.Rest:
+			signature.Rest = paramType
+		case param.Default != nil:
+			signature.Optional++
+			fallthrough
+		default:
+			signature.Params = append(signature.Params, paramType)
+			signature.Names = append(signature.Names, param.Name.Lexeme)
+		}
+	}
+	return signature
+}
+
+// checkBody checks statements of function body in a scope with its parameters,
+// default values of parameters are checked in the same scope
+func (r *Checker) checkBody(signature *FunctionType, params []*ast.Param, body []ast.Stmt) {
+	enclosingReturnType := r.returnType
+	r.returnType = signature.Return
+	r.beginScope()
+	defer func() {
+		r.endScope()
+		r.returnType = enclosingReturnType
+	}()
+
+	for i, param := range params {
+		if param.Rest {
+			r.declare(param.Name.Lexeme, List)
+			continue
+		}
+		if param.Default != nil {
+			if value := r.typeOf(param.Default); !assignable(signature.Params[i], value) {
+				r.report(param.Name, "default value of %s must be %s but is %s", param.Name.Lexeme, signature.Params[i], value)
+			}
+		}
+		r.declare(param.Name.Lexeme, signature.Params[i])
+	}
+	r.checkStatements(body)
+}
+
+// resolveType returns type named by annotation or nil when there is no annotation
+func (r *Checker) resolveType(annotation *scanning.Token) Type {
+	if annotation == nil {
+		return nil
+	}
+	t, ok := basicTypes[annotation.Lexeme]
+	if !ok {
+		r.report(annotation, "unknown type %s", annotation.Lexeme)
+		return Any
+	}
+	return t
+}
+
+func (r *Checker) resolveTypeOrAny(annotation *scanning.Token) Type {
+	return r.orAny(r.resolveType(annotation))
+}
+
+// orAny returns Any in place of missing type
+func (r *Checker) orAny(t Type) Type {
+	if t == nil {
+		return Any
+	}
+	return t
+}
+
+func (r *Checker) checkNumberOperands(operator *scanning.Token, left, right Type) {
+	if !assignable(Number, left) || !assignable(Number, right) {
+		r.report(operator, "both operands must be numbers, got %s and %s", left, right)
+	}
+}
+
+// sameNumbersOrStrings mirrors the interpreter, which accepts either two strings or two numbers
+func (r *Checker) sameNumbersOrStrings(left, right Type) bool {
+	if left == Any || right == Any {
+		return true
+	}
+	return (left == Number || left == String) && left == right
+}
+
+func (r *Checker) beginScope() {
+	r.scopes = append(r.scopes, make(map[string]Type))
+}
+
+func (r *Checker) endScope() {
+	r.scopes = r.scopes[:len(r.scopes)-1]
+}
+
+func (r *Checker) declare(name string, t Type) {
+	r.scopes[len(r.scopes)-1][name] = t
+}
+
+func (r *Checker) lookup(name *scanning.Token) Type {
+	for i := len(r.scopes) - 1; i >= 0; i-- {
+		if t, ok := r.scopes[i][name.Lexeme]; ok {
+			return t
+		}
+	}
+	r.errors = append(r.errors, &TypeError{
+		error: undefinedVariable,
+		Token: name,
+	})
+	return Any
+}
+
+func (r *Checker) report(token *scanning.Token, format string, args ...any) {
+	r.errors = append(r.errors, &TypeError{
+		error: fmt.Errorf(format, args...),
+		Token: token,
+	})
+}
@@ -0,0 +1,107 @@
+package typing
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Type is static type of Lox value as seen by Checker
+type Type interface {
+	String() string
+}
+
+type basicType string
+
+func (r basicType) String() string {
+	return string(r)
+}
+
+const (
+	Any     basicType = "any"
+	Number  basicType = "number"
+	String  basicType = "string"
+	Bool    basicType = "bool"
+	Nil     basicType = "nil"
+	List    basicType = "list"
+	Range   basicType = "range"
+	AnyFunc basicType = "fun"
+)
+
+// basicTypes maps names usable in type annotations to types
+var basicTypes = map[string]Type{
+	Any.String():     Any,
+	Number.String():  Number,
+	String.String():  String,
+	Bool.String():    Bool,
+	Nil.String():     Nil,
+	List.String():    List,
+	Range.String():   Range,
+	AnyFunc.String(): AnyFunc,
+}
+
+// FunctionType is type of function with known signature
+type FunctionType struct {
+	Params   []Type
+	Names    []string // names of Params, nil if they can't be passed by name
+	Optional int      // number of trailing Params which may be omitted
+	Rest     Type     // type of arguments following Params, nil if there can't be any
+	Return   Type
+}
+
+func (r *FunctionType) String() string {
+	params := make([]string, len(r.Params), len(r.Params)+1)
+	for i, param := range r.Params {
+		params[i] = param.String()
+		if i >= len(r.Params)-r.Optional {
+			params[i] += "?"
+		}
+	}
+	if r.Rest != nil {
+		params = append(params, "..."+r.Rest.String())
+	}
+	return fmt.Sprintf("fun(%s): %s", strings.Join(params, ", "), r.Return)
+}
+
+// assignable reports whether value of type from can be stored where type to is expected
+func assignable(to, from Type) bool {
+	if to == Any || from == Any {
+		return true
+	}
+	if to == AnyFunc {
+		_, isFunc := from.(*FunctionType)
+		return isFunc || from == AnyFunc
+	}
+	if toFunc, ok := to.(*FunctionType); ok {
+		if from == AnyFunc {
+			return true
+		}
+		fromFunc, ok := from.(*FunctionType)
+		if !ok || len(toFunc.Params) != len(fromFunc.Params) || toFunc.Optional != fromFunc.Optional ||
+			(toFunc.Rest == nil) != (fromFunc.Rest == nil) {
+			return false
+		}
+		if toFunc.Rest != nil && !assignable(fromFunc.Rest, toFunc.Rest) {
+			return false
+		}
+		for i := range toFunc.Params {
+			if !assignable(fromFunc.Params[i], toFunc.Params[i]) {
+				return false
+			}
+		}
+		return assignable(toFunc.Return, fromFunc.Return)
+	}
+	return to == from
+}
+
+// join returns type describing value of either a or b
+func join(a, b Type) Type {
+	if a == b {
+		return a
+	}
+	return Any
+}
+
+func isCallable(t Type) bool {
+	_, isFunc := t.(*FunctionType)
+	return isFunc || t == AnyFunc || t == Any
+}